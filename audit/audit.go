@@ -0,0 +1,98 @@
+// Package audit records who changed what. Every mutating endpoint appends an
+// entry through Record inside the transaction that makes the change, so an
+// entry exists exactly when the change was committed.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/logging"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// ignoredFields are bookkeeping timestamps and row versions that change on
+// every write and would only add noise to a diff
+var ignoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"createdAt":  true,
+	"updatedAt":  true,
+	"version":    true,
+}
+
+// Record appends an audit entry for the caller of ctx. store should be the
+// transaction that makes the change.
+func Record(ctx context.Context, store repository.Store, action models.AuditAction, entityType string, entityID int, changes map[string]models.FieldChange) error {
+	actor, role := Actor(ctx)
+	return store.Audit().Append(ctx, &models.AuditEntry{
+		Actor:      actor,
+		ActorRole:  role,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  logging.RequestID(ctx),
+		CreatedAt:  time.Now(),
+	})
+}
+
+// Actor identifies the caller of ctx: the token subject and role of a bearer
+// token, or the key ID of a partner request
+func Actor(ctx context.Context) (actor, role string) {
+	if principal, ok := auth.FromContext(ctx); ok {
+		return principal.Subject, string(principal.Role)
+	}
+	if key, ok := auth.APIKeyFromContext(ctx); ok {
+		return key.KeyID, "partner"
+	}
+	return "anonymous", "anonymous"
+}
+
+// Diff compares the JSON fields of two values and returns those that differ.
+// A nil before lists every field of after, as for a created entity.
+func Diff(before, after interface{}) (map[string]models.FieldChange, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]models.FieldChange{}
+	for name, value := range afterFields {
+		if old, ok := beforeFields[name]; !ok || !reflect.DeepEqual(old, value) {
+			changes[name] = models.FieldChange{Before: old, After: value}
+		}
+	}
+	for name, old := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			changes[name] = models.FieldChange{Before: old}
+		}
+	}
+	return changes, nil
+}
+
+// Helper function to flatten a value into its JSON fields
+func fields(value interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if value == nil {
+		return result, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	for name := range ignoredFields {
+		delete(result, name)
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers of a signed partner request
+const (
+	HeaderAPIKey    = "X-Api-Key"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+// maxNonceLength bounds the X-Nonce header stored per request
+const maxNonceLength = 64
+
+// NewAPIKey generates a public key ID and a secret salt for a partner
+func NewAPIKey() (keyID, salt string, err error) {
+	if keyID, err = randomHex("pk_", 8); err != nil {
+		return "", "", err
+	}
+	if salt, err = NewSalt(); err != nil {
+		return "", "", err
+	}
+	return keyID, salt, nil
+}
+
+// NewSalt generates a new secret salt, which rotates the secret of a key
+func NewSalt() (string, error) {
+	return randomHex("", 32)
+}
+
+// Helper function to generate n random bytes, hex encoded after prefix
+func randomHex(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// APIKeySecret derives the secret of a key as the HMAC-SHA256 of its key ID
+// and salt under the pepper. The database only holds the salt, so a copy of
+// it is useless without the pepper from the server's configuration.
+func (a *Authenticator) APIKeySecret(keyID, salt string) string {
+	mac := hmac.New(sha256.New, a.pepper)
+	mac.Write([]byte(keyID + "\n" + salt))
+	return "sk_" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// StringToSign builds the canonical request that is signed: the method, the
+// path with its query string, the timestamp, the nonce and the hex SHA-256 of
+// the body, separated by newlines
+func StringToSign(method, uri, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return method + "\n" + uri + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:])
+}
+
+// Sign returns the hex HMAC-SHA256 of the string to sign, keyed with the secret
+func Sign(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PartnerMiddleware authenticates partner requests signed with an API key.
+// The timestamp must be within maxSkew of the server clock and each nonce is
+// accepted once per key, so a captured request cannot be replayed.
+func PartnerMiddleware(a *Authenticator, store repository.Store, maxSkew time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		keyID, timestamp, nonce, signature := c.Get(HeaderAPIKey), c.Get(HeaderTimestamp), c.Get(HeaderNonce), c.Get(HeaderSignature)
+		if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+			return partnerUnauthorized(c, "Signed request headers "+HeaderAPIKey+", "+HeaderTimestamp+", "+HeaderNonce+" and "+HeaderSignature+" are required")
+		}
+		if len(nonce) > maxNonceLength {
+			return partnerUnauthorized(c, HeaderNonce+" must be at most "+strconv.Itoa(maxNonceLength)+" characters")
+		}
+
+		now := a.now().UTC()
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return partnerUnauthorized(c, HeaderTimestamp+" must be Unix seconds")
+		}
+		if skew := now.Sub(time.Unix(unix, 0)); skew > maxSkew || skew < -maxSkew {
+			return partnerUnauthorized(c, HeaderTimestamp+" is outside the accepted window of "+maxSkew.String())
+		}
+
+		key, err := store.APIKeys().GetByKeyID(ctx, keyID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && key.RevokedAt != nil) {
+			return partnerUnauthorized(c, "Invalid or revoked API key")
+		}
+		if err != nil {
+			return problem.Internal("Failed to load API key", err)
+		}
+
+		expected := Sign(a.APIKeySecret(key.KeyID, key.SecretSalt), StringToSign(c.Method(), c.OriginalURL(), timestamp, nonce, c.Body()))
+		if !hmac.Equal([]byte(expected), []byte(signature)) {
+			return partnerUnauthorized(c, "Invalid request signature")
+		}
+
+		// The nonce is only recorded for valid signatures, so nobody else can use it up
+		err = store.APIKeys().UseNonce(ctx, key.ID, nonce, now)
+		if errors.Is(err, repository.ErrConflict) {
+			return partnerUnauthorized(c, "Nonce has already been used")
+		}
+		if err != nil {
+			return problem.Internal("Failed to record nonce", err)
+		}
+
+		c.SetUserContext(WithAPIKey(ctx, key))
+		return c.Next()
+	}
+}
+
+// NonceRetention is how long nonces must be kept: a request is accepted until
+// maxSkew after its timestamp, which may itself be maxSkew ahead of the clock
+func NonceRetention(maxSkew time.Duration) time.Duration {
+	return 2 * maxSkew
+}
+
+// RequireScope rejects partner requests whose key lacks the scope with 403
+func RequireScope(scope models.APIKeyScope) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := APIKeyFromContext(c.UserContext())
+		if !ok {
+			return partnerUnauthorized(c, "Signed request required")
+		}
+		if !key.HasScope(scope) {
+			return Forbidden("API key lacks the " + string(scope) + " scope")
+		}
+		return c.Next()
+	}
+}
+
+// Helper function to return a 401 problem that asks for a signed request
+func partnerUnauthorized(c *fiber.Ctx, detail string) *problem.Problem {
+	c.Set(fiber.HeaderWWWAuthenticate, `HMAC-SHA256 realm="kbtg-backend"`)
+	return problem.New(fiber.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+type apiKeyKey struct{}
+
+// WithAPIKey returns a context carrying the partner key that signed the request
+func WithAPIKey(ctx context.Context, key models.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext returns the partner key stored in ctx
+func APIKeyFromContext(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(models.APIKey)
+	return key, ok
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"temp-kbtg-backend/config"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository/memory"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testMaxSkew is the accepted X-Timestamp window of the partner test server
+const testMaxSkew = 5 * time.Minute
+
+// partnerServer serves a partner route that needs the earn scope, with a fixed clock
+type partnerServer struct {
+	app   *fiber.App
+	store *memory.Store
+	auth  *Authenticator
+	now   time.Time
+}
+
+// Helper function to start a partner test server
+func newPartnerServer(t *testing.T) *partnerServer {
+	t.Helper()
+	a, err := New(config.Auth{
+		Algorithm:    "HS256",
+		Secret:       strings.Repeat("s", config.MinSecretLength),
+		TokenTTL:     time.Hour,
+		APIKeyPepper: strings.Repeat("p", config.MinSecretLength),
+	})
+	if err != nil {
+		t.Fatalf("auth: %v", err)
+	}
+	s := &partnerServer{store: memory.New(), auth: a, now: time.Unix(1_800_000_000, 0)}
+	a.now = func() time.Time { return s.now }
+
+	s.app = fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		p := problem.From(err)
+		return c.Status(p.Status).SendString(p.Detail)
+	}})
+	partner := s.app.Group("/partner", PartnerMiddleware(a, s.store, testMaxSkew))
+	handler := func(c *fiber.Ctx) error {
+		key, _ := APIKeyFromContext(c.UserContext())
+		return c.SendString(key.KeyID)
+	}
+	partner.Post("/earn", RequireScope(models.ScopeEarn), handler)
+	partner.Post("/redeem", RequireScope(models.ScopeRedeem), handler)
+	return s
+}
+
+// Helper function to create a partner key and return it with its secret
+func (s *partnerServer) createKey(t *testing.T, scopes ...models.APIKeyScope) (models.APIKey, string) {
+	t.Helper()
+	keyID, salt, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("new key: %v", err)
+	}
+	key := models.APIKey{KeyID: keyID, Name: "Partner", SecretSalt: salt, Scopes: scopes, CreatedAt: s.now}
+	if err := s.store.APIKeys().Create(context.Background(), &key); err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	return key, s.auth.APIKeySecret(keyID, salt)
+}
+
+// signedRequest describes a partner request and what its signature covers.
+// Empty signed fields sign what is sent.
+type signedRequest struct {
+	method, uri, body                   string
+	signedMethod, signedURI, signedBody string
+	timestamp                           time.Time
+	nonce                               string
+}
+
+// Helper function to send a signed request and return its status and body
+func (s *partnerServer) send(t *testing.T, keyID, secret string, r signedRequest) (int, string) {
+	t.Helper()
+	signedMethod, signedURI, signedBody := r.method, r.uri, r.body
+	if r.signedMethod != "" {
+		signedMethod = r.signedMethod
+	}
+	if r.signedURI != "" {
+		signedURI = r.signedURI
+	}
+	if r.signedBody != "" {
+		signedBody = r.signedBody
+	}
+	timestamp := strconv.FormatInt(r.timestamp.Unix(), 10)
+
+	req := httptest.NewRequest(r.method, r.uri, strings.NewReader(r.body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(HeaderAPIKey, keyID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, r.nonce)
+	req.Header.Set(HeaderSignature, Sign(secret, StringToSign(signedMethod, signedURI, timestamp, r.nonce, []byte(signedBody))))
+
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", r.method, r.uri, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestPartnerMiddlewareChecksSignature(t *testing.T) {
+	s := newPartnerServer(t)
+	key, secret := s.createKey(t, models.ScopeEarn)
+	earn := `{"membershipId": "LBK000001", "points": 10}`
+
+	tests := []struct {
+		name   string
+		req    signedRequest
+		secret string
+		status int
+		detail string
+	}{
+		{"valid signature", signedRequest{method: "POST", uri: "/partner/earn", body: earn}, secret, fiber.StatusOK, key.KeyID},
+		{"valid signature with query", signedRequest{method: "POST", uri: "/partner/earn?ref=1", body: earn}, secret, fiber.StatusOK, key.KeyID},
+		{"tampered body", signedRequest{method: "POST", uri: "/partner/earn", body: `{"membershipId": "LBK000001", "points": 1000}`, signedBody: earn}, secret, fiber.StatusUnauthorized, "Invalid request signature"},
+		{"wrong method", signedRequest{method: "POST", uri: "/partner/earn", body: earn, signedMethod: "PUT"}, secret, fiber.StatusUnauthorized, "Invalid request signature"},
+		{"wrong path", signedRequest{method: "POST", uri: "/partner/earn", body: earn, signedURI: "/partner/redeem"}, secret, fiber.StatusUnauthorized, "Invalid request signature"},
+		{"wrong query", signedRequest{method: "POST", uri: "/partner/earn?ref=2", body: earn, signedURI: "/partner/earn?ref=1"}, secret, fiber.StatusUnauthorized, "Invalid request signature"},
+		{"signed with the salt", signedRequest{method: "POST", uri: "/partner/earn", body: earn}, key.SecretSalt, fiber.StatusUnauthorized, "Invalid request signature"},
+		{"missing scope", signedRequest{method: "POST", uri: "/partner/redeem", body: earn}, secret, fiber.StatusForbidden, "API key lacks the redeem scope"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.timestamp = s.now
+			tt.req.nonce = "nonce-" + strconv.Itoa(i)
+			status, body := s.send(t, key.KeyID, tt.secret, tt.req)
+			if status != tt.status || body != tt.detail {
+				t.Errorf("got %d %q, want %d %q", status, body, tt.status, tt.detail)
+			}
+		})
+	}
+}
+
+func TestPartnerMiddlewareChecksTimestampSkew(t *testing.T) {
+	s := newPartnerServer(t)
+	key, secret := s.createKey(t, models.ScopeEarn)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		status int
+	}{
+		{"now", 0, fiber.StatusOK},
+		{"max skew in the past", -testMaxSkew, fiber.StatusOK},
+		{"max skew in the future", testMaxSkew, fiber.StatusOK},
+		{"beyond max skew in the past", -testMaxSkew - time.Second, fiber.StatusUnauthorized},
+		{"beyond max skew in the future", testMaxSkew + time.Second, fiber.StatusUnauthorized},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := s.send(t, key.KeyID, secret, signedRequest{
+				method: "POST", uri: "/partner/earn", body: `{}`,
+				timestamp: s.now.Add(tt.offset), nonce: "nonce-" + strconv.Itoa(i),
+			})
+			if status != tt.status {
+				t.Errorf("got %d %q, want %d", status, body, tt.status)
+			}
+		})
+	}
+}
+
+func TestPartnerMiddlewareRejectsReplayedNonce(t *testing.T) {
+	s := newPartnerServer(t)
+	key, secret := s.createKey(t, models.ScopeEarn)
+	req := signedRequest{method: "POST", uri: "/partner/earn", body: `{}`, timestamp: s.now, nonce: "once"}
+
+	if status, body := s.send(t, key.KeyID, secret, req); status != fiber.StatusOK {
+		t.Fatalf("first request got %d %q", status, body)
+	}
+	// The store reports the replay as ErrConflict, which is a 401
+	if status, body := s.send(t, key.KeyID, secret, req); status != fiber.StatusUnauthorized || body != "Nonce has already been used" {
+		t.Errorf("replay got %d %q", status, body)
+	}
+
+	// An invalid signature does not use up a nonce
+	req.nonce = "unused"
+	if status, _ := s.send(t, key.KeyID, "sk_wrong", req); status != fiber.StatusUnauthorized {
+		t.Fatalf("bad signature got %d", status)
+	}
+	if status, body := s.send(t, key.KeyID, secret, req); status != fiber.StatusOK {
+		t.Errorf("nonce after a bad signature got %d %q", status, body)
+	}
+}
+
+func TestPartnerMiddlewareRejectsUnknownAndRevokedKeys(t *testing.T) {
+	s := newPartnerServer(t)
+	key, secret := s.createKey(t, models.ScopeEarn)
+	if err := s.store.APIKeys().Revoke(context.Background(), key.ID, s.now); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	req := signedRequest{method: "POST", uri: "/partner/earn", body: `{}`, timestamp: s.now, nonce: "n1"}
+
+	if status, body := s.send(t, key.KeyID, secret, req); status != fiber.StatusUnauthorized || body != "Invalid or revoked API key" {
+		t.Errorf("revoked key got %d %q", status, body)
+	}
+	if status, body := s.send(t, "pk_unknown", secret, req); status != fiber.StatusUnauthorized || body != "Invalid or revoked API key" {
+		t.Errorf("unknown key got %d %q", status, body)
+	}
+}
+
+func TestPartnerMiddlewareRequiresHeaders(t *testing.T) {
+	s := newPartnerServer(t)
+	req := httptest.NewRequest("POST", "/partner/earn", strings.NewReader(`{}`))
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized || !strings.HasPrefix(resp.Header.Get(fiber.HeaderWWWAuthenticate), "HMAC-SHA256") {
+		t.Errorf("got %d with WWW-Authenticate %q", resp.StatusCode, resp.Header.Get(fiber.HeaderWWWAuthenticate))
+	}
+}
@@ -0,0 +1,182 @@
+// Package auth issues and verifies JWT bearer tokens and enforces the roles
+// of the API: members act on their own account only, support staff read and
+// manage accounts, admins may do everything. Partner integrations use API
+// keys and sign each request with HMAC-SHA256 instead of a token.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"temp-kbtg-backend/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Role is the role claim of a token
+type Role string
+
+const (
+	RoleMember  Role = "member"  // สมาชิก เข้าถึงได้เฉพาะบัญชีตัวเอง
+	RoleSupport Role = "support" // เจ้าหน้าที่ ดูและจัดการสถานะบัญชี
+	RoleAdmin   Role = "admin"   // ผู้ดูแลระบบ เข้าถึงได้ทุกอย่าง
+)
+
+// IsValid reports whether the role is known
+func (r Role) IsValid() bool {
+	return r == RoleMember || r == RoleSupport || r == RoleAdmin
+}
+
+// ErrCannotIssue is returned by Issue when no signing key is configured
+var ErrCannotIssue = errors.New("no signing key configured")
+
+// Claims are the JWT claims of an access token. For members the subject is
+// their user ID, for staff it is their name.
+type Claims struct {
+	Role Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string
+	Role    Role
+	UserID  int // User ID of a member, 0 for staff
+}
+
+// IsStaff reports whether the caller is support or admin
+func (p Principal) IsStaff() bool {
+	return p.Role == RoleSupport || p.Role == RoleAdmin
+}
+
+// Authenticator signs and verifies tokens with the configured keys and
+// derives the secrets of partner API keys
+type Authenticator struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	issuer    string
+	ttl       time.Duration
+	pepper    []byte
+	now       func() time.Time // Clock that partner request timestamps are checked against
+}
+
+// New loads the keys for the configured algorithm. Without an HS256 secret a
+// random one is generated, so tokens only last until the process restarts.
+// An API key pepper is required unless cfg.DevRandomPepper allows a random
+// one, whose partner secrets also end with the process.
+func New(cfg config.Auth) (*Authenticator, error) {
+	a := &Authenticator{issuer: cfg.Issuer, ttl: cfg.TokenTTL, pepper: []byte(cfg.APIKeyPepper), now: time.Now}
+	if len(a.pepper) == 0 {
+		if !cfg.DevRandomPepper {
+			return nil, errors.New("no API key pepper configured")
+		}
+		a.pepper = make([]byte, config.MinSecretLength)
+		if _, err := rand.Read(a.pepper); err != nil {
+			return nil, fmt.Errorf("failed to generate API key pepper: %v", err)
+		}
+		slog.Warn("No API key pepper configured, using a random one; partner secrets will not survive a restart")
+	}
+
+	switch cfg.Algorithm {
+	case "HS256":
+		secret := []byte(cfg.Secret)
+		if len(secret) == 0 {
+			secret = make([]byte, config.MinSecretLength)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, fmt.Errorf("failed to generate auth secret: %v", err)
+			}
+			slog.Warn("No auth secret configured, using a random one; tokens will not survive a restart")
+		}
+		a.method, a.signKey, a.verifyKey = jwt.SigningMethodHS256, secret, secret
+	case "RS256":
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read auth public key: %v", err)
+		}
+		if a.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+			return nil, fmt.Errorf("failed to parse auth public key: %v", err)
+		}
+		if cfg.PrivateKeyFile != "" {
+			data, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read auth private key: %v", err)
+			}
+			if a.signKey, err = jwt.ParseRSAPrivateKeyFromPEM(data); err != nil {
+				return nil, fmt.Errorf("failed to parse auth private key: %v", err)
+			}
+		}
+		a.method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("unknown auth algorithm %q", cfg.Algorithm)
+	}
+	return a, nil
+}
+
+// Issue signs a token for subject with the given role and returns it with its expiry
+func (a *Authenticator) Issue(subject string, role Role) (string, time.Time, error) {
+	if a.signKey == nil {
+		return "", time.Time{}, ErrCannotIssue
+	}
+	now := time.Now()
+	expiresAt := now.Add(a.ttl)
+	token := jwt.NewWithClaims(a.method, Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	signed, err := token.SignedString(a.signKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Verify checks the signature, algorithm, issuer and expiry of a token and
+// returns its caller
+func (a *Authenticator) Verify(tokenString string) (Principal, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims,
+		func(*jwt.Token) (interface{}, error) { return a.verifyKey, nil },
+		jwt.WithValidMethods([]string{a.method.Alg()}),
+		jwt.WithIssuer(a.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	if !claims.Role.IsValid() {
+		return Principal{}, fmt.Errorf("unknown role %q", claims.Role)
+	}
+	principal := Principal{Subject: claims.Subject, Role: claims.Role}
+	if claims.Role == RoleMember {
+		principal.UserID, err = strconv.Atoi(claims.Subject)
+		if err != nil || principal.UserID < 1 {
+			return Principal{}, fmt.Errorf("member subject %q is not a user ID", claims.Subject)
+		}
+	}
+	return principal, nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated caller
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the authenticated caller stored in ctx
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"strings"
+	"temp-kbtg-backend/problem"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error codes for rejected requests
+const (
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+)
+
+// Middleware authenticates the bearer token of every request that sends one
+// and stores the caller in the request's UserContext. Requests without a
+// token pass through anonymously; routes that need a caller use Require.
+func Middleware(a *Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			return c.Next()
+		}
+
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return Unauthorized(c, "Authorization header must be a Bearer token")
+		}
+		principal, err := a.Verify(strings.TrimSpace(token))
+		if err != nil {
+			return Unauthorized(c, "Invalid or expired token").WithCause(err)
+		}
+
+		c.SetUserContext(WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}
+}
+
+// Require rejects requests without a token with 401 and callers whose role
+// is not listed with 403
+func Require(roles ...Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := FromContext(c.UserContext())
+		if !ok {
+			return Unauthorized(c, "Authentication required")
+		}
+		for _, role := range roles {
+			if principal.Role == role {
+				return c.Next()
+			}
+		}
+		return Forbidden("Role " + string(principal.Role) + " may not access this resource")
+	}
+}
+
+// Unauthorized returns a 401 problem and asks the client for a bearer token
+func Unauthorized(c *fiber.Ctx, detail string) *problem.Problem {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="kbtg-backend"`)
+	return problem.New(fiber.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+// Forbidden returns a 403 problem for an authenticated caller that may not act
+func Forbidden(detail string) *problem.Problem {
+	return problem.New(fiber.StatusForbidden, CodeForbidden, detail)
+}
@@ -0,0 +1,671 @@
+// Package config loads the server settings from defaults, an optional YAML
+// file, environment variables and command-line flags, in that order of
+// precedence (later sources win).
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the effective configuration of the server
+type Config struct {
+	ListenAddr     string     `yaml:"listen_addr"`
+	Database       Database   `yaml:"database"`
+	CORS           CORS       `yaml:"cors"`
+	Pagination     Pagination `yaml:"pagination"`
+	SeedSampleData bool       `yaml:"seed_sample_data"`
+	LogLevel       string     `yaml:"log_level"`
+
+	// ShutdownTimeout bounds how long SIGINT/SIGTERM waits for in-flight
+	// requests and background workers before the database is closed
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	Tracing   Tracing   `yaml:"tracing"`
+	Auth      Auth      `yaml:"auth"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Rules     Rules     `yaml:"rules"`
+}
+
+// Auth configures JWT bearer authentication. HS256 signs and verifies with
+// Secret, RS256 verifies with PublicKeyFile and signs with PrivateKeyFile.
+type Auth struct {
+	Algorithm      string        `yaml:"algorithm"`        // HS256 or RS256
+	Secret         string        `yaml:"secret"`           // HS256 key, at least 32 bytes; random per process when empty
+	PublicKeyFile  string        `yaml:"public_key_file"`  // RS256 verification key (PEM)
+	PrivateKeyFile string        `yaml:"private_key_file"` // RS256 signing key (PEM), only needed to issue tokens
+	Issuer         string        `yaml:"issuer"`           // iss claim issued and required
+	TokenTTL       time.Duration `yaml:"token_ttl"`        // Lifetime of issued tokens
+	DevLogin       bool          `yaml:"dev_login"`        // Enables POST /auth/token, never enable in production
+
+	SignatureMaxSkew time.Duration `yaml:"signature_max_skew"` // Accepted age of a signed partner request's X-Timestamp
+	APIKeyPepper     string        `yaml:"api_key_pepper"`     // Derives partner secrets, at least 32 bytes; required unless DevRandomPepper
+	DevRandomPepper  bool          `yaml:"dev_random_pepper"`  // Allows an empty APIKeyPepper and uses a random one per process, never enable in production
+}
+
+// RateLimit configures the token buckets that limit each client per route
+// group. A client is its API key, its authenticated user or its IP address.
+type RateLimit struct {
+	Enabled    bool   `yaml:"enabled"`
+	Store      string `yaml:"store"`       // memory or sqlite
+	SQLitePath string `yaml:"sqlite_path"` // Bucket file of the sqlite store, survives restarts and is shared on the host
+
+	IP      Limit `yaml:"ip"`      // Every limited endpoint, per IP address, counted before authentication
+	Auth    Limit `yaml:"auth"`    // POST /auth/token
+	Read    Limit `yaml:"read"`    // GET endpoints
+	Write   Limit `yaml:"write"`   // POST, PUT and DELETE endpoints
+	Partner Limit `yaml:"partner"` // Signed partner endpoints, per API key
+}
+
+// Limit is a token bucket holding up to Burst requests, refilled at Requests per Period
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+// String formats the limit as requests/period with its burst, e.g. 60/1m0s burst 20
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s burst %d", l.Requests, l.Period, l.Burst)
+}
+
+// Rules sets the thresholds of the fraud and velocity rules checked before
+// every transfer. Each rule's decision is review, which holds the transfer
+// for staff, or deny.
+type Rules struct {
+	Velocity     VelocityRule     `yaml:"velocity"`      // Transfers a sender may make per window
+	NewRecipient NewRecipientRule `yaml:"new_recipient"` // Largest first transfer to a recipient
+	FanIn        FanInRule        `yaml:"fan_in"`        // Distinct senders a receiver may get per window
+	AccountAge   AccountAgeRule   `yaml:"account_age"`   // Largest transfer from a recently joined sender
+}
+
+// VelocityRule flags a sender's transfer once MaxTransfers were sent within Window
+type VelocityRule struct {
+	MaxTransfers int           `yaml:"max_transfers"`
+	Window       time.Duration `yaml:"window"`
+	Decision     string        `yaml:"decision"`
+}
+
+// NewRecipientRule flags a first transfer to a recipient above MaxAmount
+type NewRecipientRule struct {
+	MaxAmount int    `yaml:"max_amount"`
+	Decision  string `yaml:"decision"`
+}
+
+// FanInRule flags a transfer that makes more than MaxSenders senders to one receiver within Window
+type FanInRule struct {
+	MaxSenders int           `yaml:"max_senders"`
+	Window     time.Duration `yaml:"window"`
+	Decision   string        `yaml:"decision"`
+}
+
+// AccountAgeRule flags transfers above MaxAmount from accounts younger than MinAge
+type AccountAgeRule struct {
+	MinAge    time.Duration `yaml:"min_age"`
+	MaxAmount int           `yaml:"max_amount"`
+	Decision  string        `yaml:"decision"`
+}
+
+// MinSecretLength is the shortest HS256 secret accepted
+const MinSecretLength = 32
+
+// Tracing selects where OpenTelemetry spans are exported. Trace IDs are
+// generated for logs and error responses even when Exporter is none.
+type Tracing struct {
+	Exporter     string  `yaml:"exporter"`      // none, stdout, file or otlp
+	File         string  `yaml:"file"`          // Output path for the file exporter
+	OTLPEndpoint string  `yaml:"otlp_endpoint"` // host:port of an OTLP/HTTP collector
+	SampleRatio  float64 `yaml:"sample_ratio"`  // Fraction of new traces to record
+}
+
+// Database selects the storage backend. DSN is the file path for sqlite
+// and the connection URL for postgres.
+type Database struct {
+	Driver string `yaml:"driver"`
+	DSN    string `yaml:"dsn"`
+}
+
+// CORS lists the origins allowed to call the API from a browser
+type CORS struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+}
+
+// Pagination bounds the page size of list endpoints
+type Pagination struct {
+	DefaultPageSize int `yaml:"default_page_size"`
+	MaxPageSize     int `yaml:"max_page_size"`
+}
+
+// DefaultSQLiteDSN is the database file used when no DSN is configured
+const DefaultSQLiteDSN = "./users.db"
+
+// Default returns the settings the server used before it was configurable
+func Default() Config {
+	return Config{
+		ListenAddr: ":3000",
+		Database: Database{
+			Driver: "sqlite",
+		},
+		CORS: CORS{
+			AllowOrigins: []string{"*"},
+		},
+		Pagination: Pagination{
+			DefaultPageSize: 20,
+			MaxPageSize:     200,
+		},
+		SeedSampleData:  true,
+		LogLevel:        "info",
+		ShutdownTimeout: 10 * time.Second,
+		Tracing: Tracing{
+			Exporter:     "none",
+			File:         "./traces.jsonl",
+			OTLPEndpoint: "localhost:4318",
+			SampleRatio:  1,
+		},
+		Auth: Auth{
+			Algorithm: "HS256",
+			Issuer:    "kbtg-backend",
+			TokenTTL:  time.Hour,
+
+			SignatureMaxSkew: 5 * time.Minute,
+		},
+		RateLimit: RateLimit{
+			Enabled:    true,
+			Store:      "memory",
+			SQLitePath: "./ratelimit.db",
+			IP:         Limit{Requests: 1200, Period: time.Minute, Burst: 200},
+			Auth:       Limit{Requests: 10, Period: time.Minute, Burst: 5},
+			Read:       Limit{Requests: 300, Period: time.Minute, Burst: 100},
+			Write:      Limit{Requests: 60, Period: time.Minute, Burst: 20},
+			Partner:    Limit{Requests: 1200, Period: time.Minute, Burst: 200},
+		},
+		Rules: Rules{
+			Velocity:     VelocityRule{MaxTransfers: 10, Window: 10 * time.Minute, Decision: "deny"},
+			NewRecipient: NewRecipientRule{MaxAmount: 5000, Decision: "review"},
+			FanIn:        FanInRule{MaxSenders: 20, Window: time.Hour, Decision: "review"},
+			AccountAge:   AccountAgeRule{MinAge: 7 * 24 * time.Hour, MaxAmount: 1000, Decision: "review"},
+		},
+	}
+}
+
+// Flags holds the command-line flags registered by BindFlags
+type Flags struct {
+	fs              *flag.FlagSet
+	configFile      *string
+	listenAddr      *string
+	dbDriver        *string
+	dbDSN           *string
+	corsOrigins     *string
+	seedSampleData  *bool
+	logLevel        *string
+	shutdownTimeout *time.Duration
+	traceExporter   *string
+	devLogin        *bool
+	devRandomPepper *bool
+}
+
+// BindFlags registers the configuration flags on fs. Call Load after fs is parsed.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		fs:              fs,
+		configFile:      fs.String("config", "", "path to a YAML config file (env CONFIG_FILE)"),
+		listenAddr:      fs.String("listen", "", "listen address, e.g. :3000 (env LISTEN_ADDR)"),
+		dbDriver:        fs.String("db-driver", "", "database driver: sqlite or postgres (env DB_DRIVER)"),
+		dbDSN:           fs.String("db-dsn", "", "sqlite file path or postgres URL (env DATABASE_URL)"),
+		corsOrigins:     fs.String("cors-origins", "", "comma-separated allowed CORS origins (env CORS_ALLOW_ORIGINS)"),
+		seedSampleData:  fs.Bool("seed-sample-data", true, "insert sample users into an empty sqlite database (env SEED_SAMPLE_DATA)"),
+		logLevel:        fs.String("log-level", "", "debug, info, warn or error (env LOG_LEVEL)"),
+		shutdownTimeout: fs.Duration("shutdown-timeout", 0, "how long to wait for in-flight requests on shutdown, e.g. 10s (env SHUTDOWN_TIMEOUT)"),
+		traceExporter:   fs.String("trace-exporter", "", "none, stdout, file or otlp (env TRACING_EXPORTER)"),
+		devLogin:        fs.Bool("dev-login", false, "enable POST /auth/token to issue tokens without a password (env AUTH_DEV_LOGIN)"),
+		devRandomPepper: fs.Bool("dev-random-pepper", false, "use a random API key pepper when none is configured (env AUTH_DEV_RANDOM_PEPPER)"),
+	}
+}
+
+// Load builds the effective configuration and validates it
+func Load(flags *Flags) (Config, error) {
+	cfg := Default()
+
+	// The config file can only be chosen by flag or env
+	path := os.Getenv("CONFIG_FILE")
+	if flags != nil && isSet(flags.fs, "config") {
+		path = *flags.configFile
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	if flags != nil {
+		flags.apply(&cfg)
+	}
+
+	// sqlite falls back to the local database file
+	if cfg.Database.DSN == "" && cfg.Database.Driver == "sqlite" {
+		cfg.Database.DSN = DefaultSQLiteDSN
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Validate reports every invalid setting at once
+func (c Config) Validate() error {
+	var problems []string
+
+	if _, port, err := net.SplitHostPort(c.ListenAddr); err != nil || port == "" {
+		problems = append(problems, fmt.Sprintf("listen_addr %q must be host:port, e.g. :3000", c.ListenAddr))
+	}
+
+	switch c.Database.Driver {
+	case "sqlite", "postgres":
+		if c.Database.DSN == "" {
+			problems = append(problems, fmt.Sprintf("database.dsn is required for driver %s", c.Database.Driver))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver %q must be sqlite or postgres", c.Database.Driver))
+	}
+
+	if len(c.CORS.AllowOrigins) == 0 {
+		problems = append(problems, "cors.allow_origins must list at least one origin")
+	}
+
+	if c.Pagination.DefaultPageSize < 1 {
+		problems = append(problems, "pagination.default_page_size must be at least 1")
+	}
+	if c.Pagination.MaxPageSize < c.Pagination.DefaultPageSize {
+		problems = append(problems, "pagination.max_page_size must not be smaller than default_page_size")
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("log_level %q must be debug, info, warn or error", c.LogLevel))
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		problems = append(problems, "shutdown_timeout must be positive, e.g. 10s")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "file":
+		if c.Tracing.File == "" {
+			problems = append(problems, "tracing.file is required for the file exporter")
+		}
+	case "otlp":
+		if c.Tracing.OTLPEndpoint == "" {
+			problems = append(problems, "tracing.otlp_endpoint is required for the otlp exporter")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter %q must be none, stdout, file or otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio must be between 0 and 1")
+	}
+
+	switch c.Auth.Algorithm {
+	case "HS256":
+		if c.Auth.Secret != "" && len(c.Auth.Secret) < MinSecretLength {
+			problems = append(problems, fmt.Sprintf("auth.secret must be at least %d bytes", MinSecretLength))
+		}
+	case "RS256":
+		if c.Auth.PublicKeyFile == "" {
+			problems = append(problems, "auth.public_key_file is required for RS256")
+		}
+		if c.Auth.DevLogin && c.Auth.PrivateKeyFile == "" {
+			problems = append(problems, "auth.private_key_file is required for RS256 with dev_login")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("auth.algorithm %q must be HS256 or RS256", c.Auth.Algorithm))
+	}
+	if c.Auth.TokenTTL <= 0 {
+		problems = append(problems, "auth.token_ttl must be positive, e.g. 1h")
+	}
+	if c.Auth.SignatureMaxSkew <= 0 {
+		problems = append(problems, "auth.signature_max_skew must be positive, e.g. 5m")
+	}
+	// The partner API is always served, and a random pepper would break every
+	// issued secret on restart, so only development may go without one
+	switch {
+	case c.Auth.APIKeyPepper == "" && !c.Auth.DevRandomPepper:
+		problems = append(problems, "auth.api_key_pepper is required (set auth.dev_random_pepper to use a random one in development)")
+	case c.Auth.APIKeyPepper != "" && len(c.Auth.APIKeyPepper) < MinSecretLength:
+		problems = append(problems, fmt.Sprintf("auth.api_key_pepper must be at least %d bytes", MinSecretLength))
+	}
+
+	switch c.RateLimit.Store {
+	case "memory":
+	case "sqlite":
+		if c.RateLimit.SQLitePath == "" {
+			problems = append(problems, "rate_limit.sqlite_path is required for the sqlite store")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("rate_limit.store %q must be memory or sqlite", c.RateLimit.Store))
+	}
+	for _, group := range []struct {
+		name  string
+		limit Limit
+	}{{"ip", c.RateLimit.IP}, {"auth", c.RateLimit.Auth}, {"read", c.RateLimit.Read}, {"write", c.RateLimit.Write}, {"partner", c.RateLimit.Partner}} {
+		if group.limit.Requests < 1 || group.limit.Period <= 0 || group.limit.Burst < 1 {
+			problems = append(problems, fmt.Sprintf("rate_limit.%s needs positive requests, period and burst", group.name))
+		}
+	}
+
+	r := c.Rules
+	if r.Velocity.MaxTransfers < 1 || r.Velocity.Window <= 0 {
+		problems = append(problems, "rules.velocity needs positive max_transfers and window")
+	}
+	if r.NewRecipient.MaxAmount < 1 {
+		problems = append(problems, "rules.new_recipient.max_amount must be positive")
+	}
+	if r.FanIn.MaxSenders < 1 || r.FanIn.Window <= 0 {
+		problems = append(problems, "rules.fan_in needs positive max_senders and window")
+	}
+	if r.AccountAge.MinAge <= 0 || r.AccountAge.MaxAmount < 1 {
+		problems = append(problems, "rules.account_age needs positive min_age and max_amount")
+	}
+	for _, rule := range []struct{ name, decision string }{
+		{"velocity", r.Velocity.Decision}, {"new_recipient", r.NewRecipient.Decision},
+		{"fan_in", r.FanIn.Decision}, {"account_age", r.AccountAge.Decision},
+	} {
+		if rule.decision != "review" && rule.decision != "deny" {
+			problems = append(problems, fmt.Sprintf("rules.%s.decision %q must be review or deny", rule.name, rule.decision))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Redacted returns a copy that is safe to log, with passwords in the DSN, the
+// auth secret and the API key pepper masked
+func (c Config) Redacted() Config {
+	redacted := c
+	if c.Auth.Secret != "" {
+		redacted.Auth.Secret = "xxxxx"
+	}
+	if c.Auth.APIKeyPepper != "" {
+		redacted.Auth.APIKeyPepper = "xxxxx"
+	}
+	redacted.CORS.AllowOrigins = append([]string(nil), c.CORS.AllowOrigins...)
+	if u, err := url.Parse(c.Database.DSN); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			redacted.Database.DSN = u.String()
+		}
+	}
+	if strings.Contains(strings.ToLower(redacted.Database.DSN), "password=") {
+		redacted.Database.DSN = redactKeyValue(redacted.Database.DSN)
+	}
+	return redacted
+}
+
+// LogValue logs the redacted configuration as a structured group
+func (c Config) LogValue() slog.Value {
+	r := c.Redacted()
+	return slog.GroupValue(
+		slog.String("listen_addr", r.ListenAddr),
+		slog.Group("database",
+			slog.String("driver", r.Database.Driver),
+			slog.String("dsn", r.Database.DSN),
+		),
+		slog.Group("cors",
+			slog.Any("allow_origins", r.CORS.AllowOrigins),
+		),
+		slog.Group("pagination",
+			slog.Int("default_page_size", r.Pagination.DefaultPageSize),
+			slog.Int("max_page_size", r.Pagination.MaxPageSize),
+		),
+		slog.Bool("seed_sample_data", r.SeedSampleData),
+		slog.String("log_level", r.LogLevel),
+		slog.String("shutdown_timeout", r.ShutdownTimeout.String()),
+		slog.Group("tracing",
+			slog.String("exporter", r.Tracing.Exporter),
+			slog.String("file", r.Tracing.File),
+			slog.String("otlp_endpoint", r.Tracing.OTLPEndpoint),
+			slog.Float64("sample_ratio", r.Tracing.SampleRatio),
+		),
+		slog.Group("auth",
+			slog.String("algorithm", r.Auth.Algorithm),
+			slog.String("secret", r.Auth.Secret),
+			slog.String("public_key_file", r.Auth.PublicKeyFile),
+			slog.String("private_key_file", r.Auth.PrivateKeyFile),
+			slog.String("issuer", r.Auth.Issuer),
+			slog.String("token_ttl", r.Auth.TokenTTL.String()),
+			slog.Bool("dev_login", r.Auth.DevLogin),
+			slog.String("signature_max_skew", r.Auth.SignatureMaxSkew.String()),
+			slog.String("api_key_pepper", r.Auth.APIKeyPepper),
+			slog.Bool("dev_random_pepper", r.Auth.DevRandomPepper),
+		),
+		slog.Group("rate_limit",
+			slog.Bool("enabled", r.RateLimit.Enabled),
+			slog.String("store", r.RateLimit.Store),
+			slog.String("sqlite_path", r.RateLimit.SQLitePath),
+			slog.String("ip", r.RateLimit.IP.String()),
+			slog.String("auth", r.RateLimit.Auth.String()),
+			slog.String("read", r.RateLimit.Read.String()),
+			slog.String("write", r.RateLimit.Write.String()),
+			slog.String("partner", r.RateLimit.Partner.String()),
+		),
+		slog.Group("rules",
+			slog.String("velocity", fmt.Sprintf("%d/%s %s", r.Rules.Velocity.MaxTransfers, r.Rules.Velocity.Window, r.Rules.Velocity.Decision)),
+			slog.String("new_recipient", fmt.Sprintf("%d %s", r.Rules.NewRecipient.MaxAmount, r.Rules.NewRecipient.Decision)),
+			slog.String("fan_in", fmt.Sprintf("%d/%s %s", r.Rules.FanIn.MaxSenders, r.Rules.FanIn.Window, r.Rules.FanIn.Decision)),
+			slog.String("account_age", fmt.Sprintf("%s %d %s", r.Rules.AccountAge.MinAge, r.Rules.AccountAge.MaxAmount, r.Rules.AccountAge.Decision)),
+		),
+	)
+}
+
+// Helper function to read a YAML file over the defaults
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// Helper function to apply environment variables
+func applyEnv(cfg *Config) error {
+	if v, ok := os.LookupEnv("LISTEN_ADDR"); ok && v != "" {
+		cfg.ListenAddr = v
+	}
+	if v, ok := os.LookupEnv("DB_DRIVER"); ok && v != "" {
+		cfg.Database.Driver = v
+	}
+	if v, ok := os.LookupEnv("DATABASE_URL"); ok && v != "" {
+		cfg.Database.DSN = v
+	}
+	if v, ok := os.LookupEnv("CORS_ALLOW_ORIGINS"); ok {
+		cfg.CORS.AllowOrigins = splitList(v)
+	}
+	if v, ok := os.LookupEnv("DEFAULT_PAGE_SIZE"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("DEFAULT_PAGE_SIZE must be an integer: %v", err)
+		}
+		cfg.Pagination.DefaultPageSize = n
+	}
+	if v, ok := os.LookupEnv("MAX_PAGE_SIZE"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("MAX_PAGE_SIZE must be an integer: %v", err)
+		}
+		cfg.Pagination.MaxPageSize = n
+	}
+	if v, ok := os.LookupEnv("SEED_SAMPLE_DATA"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("SEED_SAMPLE_DATA must be true or false: %v", err)
+		}
+		cfg.SeedSampleData = b
+	}
+	if v, ok := os.LookupEnv("LOG_LEVEL"); ok && v != "" {
+		cfg.LogLevel = strings.ToLower(v)
+	}
+	if v, ok := os.LookupEnv("SHUTDOWN_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("SHUTDOWN_TIMEOUT must be a duration such as 10s: %v", err)
+		}
+		cfg.ShutdownTimeout = d
+	}
+	if v, ok := os.LookupEnv("TRACING_EXPORTER"); ok && v != "" {
+		cfg.Tracing.Exporter = strings.ToLower(v)
+	}
+	if v, ok := os.LookupEnv("TRACING_FILE"); ok && v != "" {
+		cfg.Tracing.File = v
+	}
+	if v, ok := os.LookupEnv("TRACING_OTLP_ENDPOINT"); ok && v != "" {
+		cfg.Tracing.OTLPEndpoint = v
+	}
+	if v, ok := os.LookupEnv("TRACING_SAMPLE_RATIO"); ok && v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("TRACING_SAMPLE_RATIO must be a number between 0 and 1: %v", err)
+		}
+		cfg.Tracing.SampleRatio = ratio
+	}
+	if v, ok := os.LookupEnv("AUTH_ALGORITHM"); ok && v != "" {
+		cfg.Auth.Algorithm = strings.ToUpper(v)
+	}
+	if v, ok := os.LookupEnv("AUTH_SECRET"); ok && v != "" {
+		cfg.Auth.Secret = v
+	}
+	if v, ok := os.LookupEnv("AUTH_PUBLIC_KEY_FILE"); ok && v != "" {
+		cfg.Auth.PublicKeyFile = v
+	}
+	if v, ok := os.LookupEnv("AUTH_PRIVATE_KEY_FILE"); ok && v != "" {
+		cfg.Auth.PrivateKeyFile = v
+	}
+	if v, ok := os.LookupEnv("AUTH_ISSUER"); ok && v != "" {
+		cfg.Auth.Issuer = v
+	}
+	if v, ok := os.LookupEnv("AUTH_TOKEN_TTL"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("AUTH_TOKEN_TTL must be a duration such as 1h: %v", err)
+		}
+		cfg.Auth.TokenTTL = d
+	}
+	if v, ok := os.LookupEnv("AUTH_DEV_LOGIN"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("AUTH_DEV_LOGIN must be true or false: %v", err)
+		}
+		cfg.Auth.DevLogin = b
+	}
+	if v, ok := os.LookupEnv("AUTH_SIGNATURE_MAX_SKEW"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("AUTH_SIGNATURE_MAX_SKEW must be a duration such as 5m: %v", err)
+		}
+		cfg.Auth.SignatureMaxSkew = d
+	}
+	if v, ok := os.LookupEnv("AUTH_API_KEY_PEPPER"); ok && v != "" {
+		cfg.Auth.APIKeyPepper = v
+	}
+	if v, ok := os.LookupEnv("AUTH_DEV_RANDOM_PEPPER"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("AUTH_DEV_RANDOM_PEPPER must be true or false: %v", err)
+		}
+		cfg.Auth.DevRandomPepper = b
+	}
+	if v, ok := os.LookupEnv("RATE_LIMIT_ENABLED"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_ENABLED must be true or false: %v", err)
+		}
+		cfg.RateLimit.Enabled = b
+	}
+	if v, ok := os.LookupEnv("RATE_LIMIT_STORE"); ok && v != "" {
+		cfg.RateLimit.Store = v
+	}
+	if v, ok := os.LookupEnv("RATE_LIMIT_SQLITE_PATH"); ok && v != "" {
+		cfg.RateLimit.SQLitePath = v
+	}
+	return nil
+}
+
+// Helper function to apply the flags that were given on the command line
+func (f *Flags) apply(cfg *Config) {
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "listen":
+			cfg.ListenAddr = *f.listenAddr
+		case "db-driver":
+			cfg.Database.Driver = *f.dbDriver
+		case "db-dsn":
+			cfg.Database.DSN = *f.dbDSN
+		case "cors-origins":
+			cfg.CORS.AllowOrigins = splitList(*f.corsOrigins)
+		case "seed-sample-data":
+			cfg.SeedSampleData = *f.seedSampleData
+		case "log-level":
+			cfg.LogLevel = strings.ToLower(*f.logLevel)
+		case "shutdown-timeout":
+			cfg.ShutdownTimeout = *f.shutdownTimeout
+		case "trace-exporter":
+			cfg.Tracing.Exporter = strings.ToLower(*f.traceExporter)
+		case "dev-login":
+			cfg.Auth.DevLogin = *f.devLogin
+		case "dev-random-pepper":
+			cfg.Auth.DevRandomPepper = *f.devRandomPepper
+		}
+	})
+}
+
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Helper function to split a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Helper function to mask password=... in key/value style DSNs
+func redactKeyValue(dsn string) string {
+	fields := strings.Fields(dsn)
+	for i, field := range fields {
+		if strings.HasPrefix(strings.ToLower(field), "password=") {
+			fields[i] = "password=xxxxx"
+		}
+	}
+	return strings.Join(fields, " ")
+}
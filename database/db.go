@@ -1,18 +1,36 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
+	"temp-kbtg-backend/database/migrations"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/tracing"
 
 	_ "github.com/mattn/go-sqlite3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 var DB *sql.DB
 
-func InitDB() error {
+// DSNOptions enables foreign key enforcement on every connection in the pool.
+// WAL lets readers run alongside the single writer, busy_timeout waits for
+// the write lock instead of failing at once, and _txlock=immediate makes
+// every transaction take the write lock at BEGIN.
+const DSNOptions = "_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+
+// Open connects to the database file at path without creating or checking the schema
+func Open(path string) error {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
 	var err error
-	DB, err = sql.Open("sqlite3", "./users.db")
+	DB, err = tracing.OpenDB("sqlite3", path+separator+DSNOptions, semconv.DBSystemSqlite)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %v", err)
 	}
@@ -22,103 +40,113 @@ func InitDB() error {
 		return fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	// Create users table
-	createTableQuery := `
-	CREATE TABLE IF NOT EXISTS users (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		membership_id TEXT UNIQUE NOT NULL,
-		first_name TEXT NOT NULL,
-		last_name TEXT NOT NULL,
-		phone_number TEXT NOT NULL,
-		email TEXT UNIQUE NOT NULL,
-		membership_level TEXT DEFAULT 'Bronze',
-		points INTEGER DEFAULT 0,
-		joined_date DATETIME DEFAULT CURRENT_TIMESTAMP,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	if _, err = DB.Exec(createTableQuery); err != nil {
-		return fmt.Errorf("failed to create users table: %v", err)
-	}
-
-	// Create transfers table
-	createTransfersTable := `
-	CREATE TABLE IF NOT EXISTS transfers (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		from_user_id INTEGER NOT NULL,
-		to_user_id INTEGER NOT NULL,
-		amount INTEGER NOT NULL CHECK (amount > 0),
-		status TEXT NOT NULL CHECK (status IN ('pending','processing','completed','failed','cancelled','reversed')),
-		note TEXT,
-		idempotency_key TEXT NOT NULL UNIQUE,
-		created_at TEXT NOT NULL,
-		updated_at TEXT NOT NULL,
-		completed_at TEXT,
-		fail_reason TEXT,
-		FOREIGN KEY (from_user_id) REFERENCES users(id),
-		FOREIGN KEY (to_user_id) REFERENCES users(id)
-	);`
-
-	if _, err = DB.Exec(createTransfersTable); err != nil {
-		return fmt.Errorf("failed to create transfers table: %v", err)
-	}
-
-	// Create indexes for transfers
-	transferIndexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_transfers_from ON transfers(from_user_id);",
-		"CREATE INDEX IF NOT EXISTS idx_transfers_to ON transfers(to_user_id);",
-		"CREATE INDEX IF NOT EXISTS idx_transfers_created ON transfers(created_at);",
-		"CREATE INDEX IF NOT EXISTS idx_transfers_idem_key ON transfers(idempotency_key);",
-	}
-
-	for _, indexSQL := range transferIndexes {
-		if _, err = DB.Exec(indexSQL); err != nil {
-			return fmt.Errorf("failed to create transfer index: %v", err)
-		}
+	return nil
+}
+
+// InitDB opens the database, applies pending migrations and checks integrity.
+// Sample users are inserted into an empty database when seedSampleData is set.
+func InitDB(path string, seedSampleData bool) error {
+	err := Open(path)
+	if err != nil {
+		return err
 	}
 
-	// Create point_ledger table
-	createLedgerTable := `
-	CREATE TABLE IF NOT EXISTS point_ledger (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		user_id INTEGER NOT NULL,
-		change INTEGER NOT NULL,
-		balance_after INTEGER NOT NULL,
-		event_type TEXT NOT NULL CHECK (event_type IN ('transfer_out','transfer_in','adjust','earn','redeem')),
-		transfer_id INTEGER,
-		reference TEXT,
-		metadata TEXT,
-		created_at TEXT NOT NULL,
-		FOREIGN KEY (user_id) REFERENCES users(id),
-		FOREIGN KEY (transfer_id) REFERENCES transfers(id)
-	);`
-
-	if _, err = DB.Exec(createLedgerTable); err != nil {
-		return fmt.Errorf("failed to create point_ledger table: %v", err)
-	}
-
-	// Create indexes for point_ledger
-	ledgerIndexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_ledger_user ON point_ledger(user_id);",
-		"CREATE INDEX IF NOT EXISTS idx_ledger_transfer ON point_ledger(transfer_id);",
-		"CREATE INDEX IF NOT EXISTS idx_ledger_created ON point_ledger(created_at);",
-	}
-
-	for _, indexSQL := range ledgerIndexes {
-		if _, err = DB.Exec(indexSQL); err != nil {
-			return fmt.Errorf("failed to create ledger index: %v", err)
+	// Bring databases created before versioned migrations up to the 0001 schema
+	if err = upgradeLegacySchema(); err != nil {
+		return err
+	}
+
+	migrator, err := migrations.New(DB, migrations.SQLite)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to run against a schema written by a newer binary
+	if err = migrator.CheckVersion(context.Background()); err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, migration := range applied {
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	// Refuse to start on a corrupt database or one with dangling references
+	report, err := CheckIntegrity()
+	if err != nil {
+		return fmt.Errorf("failed to check database integrity: %v", err)
+	}
+	if !report.OK() {
+		for _, line := range report.Lines() {
+			slog.Error("Integrity problem", "problem", line)
 		}
+		return fmt.Errorf("database integrity check failed: %s (run with -integrity-report for details)", report.Summary())
 	}
 
-	log.Println("✅ Database initialized successfully")
+	slog.Info("Database initialized")
 
 	// Insert sample data if table is empty
-	insertSampleData()
+	if seedSampleData {
+		insertSampleData()
+	}
+
+	// Create the account that receives points forfeited by closed accounts
+	if err = ensureSystemAccount(); err != nil {
+		return fmt.Errorf("failed to create system account: %v", err)
+	}
+
+	return nil
+}
 
+// upgradeLegacySchema adds the account status columns to users tables created
+// before they existed, so that migration 0001 can be recorded as applied
+func upgradeLegacySchema() error {
+	var count int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&count); err != nil {
+		return fmt.Errorf("failed to inspect schema: %v", err)
+	}
+	if count == 0 {
+		return nil
+	}
+
+	if err := addColumnIfMissing("users", "status", "TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active','frozen','suspended','closed'))"); err != nil {
+		return fmt.Errorf("failed to add users.status column: %v", err)
+	}
+	if err := addColumnIfMissing("users", "closed_at", "DATETIME"); err != nil {
+		return fmt.Errorf("failed to add users.closed_at column: %v", err)
+	}
 	return nil
 }
 
+// addColumnIfMissing adds a column to an existing table, since CREATE TABLE IF NOT EXISTS
+// does not change tables that already exist
+func addColumnIfMissing(table, column, definition string) error {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	rows.Close()
+
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
 func insertSampleData() {
 	var count int
 	DB.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
@@ -133,16 +161,42 @@ func insertSampleData() {
 		`
 
 		if _, err := DB.Exec(insertQuery); err != nil {
-			log.Printf("Failed to insert sample data: %v", err)
+			slog.Error("Failed to insert sample data", "error", err)
 		} else {
-			log.Println("✅ Sample data inserted")
+			slog.Info("Sample data inserted")
 		}
 	}
 }
 
+func ensureSystemAccount() error {
+	_, err := DB.Exec(`
+		INSERT OR IGNORE INTO users (membership_id, first_name, last_name, phone_number, email, membership_level, points, status)
+		VALUES (?, 'System', 'Account', '-', 'system@kbtg.local', 'System', 0, 'frozen')
+	`, models.SystemMembershipID)
+	return err
+}
+
+// Checkpoint copies the WAL back into the database file. PASSIVE never blocks
+// writers; TRUNCATE waits for them and empties the WAL file.
+func Checkpoint(ctx context.Context, mode string) error {
+	var busy, logFrames, checkpointed int
+	err := DB.QueryRowContext(ctx, "PRAGMA wal_checkpoint("+mode+")").Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %v", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("WAL checkpoint (%s) was blocked by another connection", mode)
+	}
+	return nil
+}
+
+// CloseDB checkpoints the WAL so users.db is complete on its own, then closes the pool
 func CloseDB() {
 	if DB != nil {
+		if err := Checkpoint(context.Background(), "TRUNCATE"); err != nil {
+			slog.Warn("WAL checkpoint on close failed", "error", err)
+		}
 		DB.Close()
-		log.Println("Database connection closed")
+		slog.Info("Database connection closed")
 	}
 }
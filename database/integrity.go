@@ -0,0 +1,177 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ForeignKeyViolation is a row reported by PRAGMA foreign_key_check
+type ForeignKeyViolation struct {
+	Table  string `json:"table"`
+	RowID  int64  `json:"rowId"`
+	Parent string `json:"parent"`
+}
+
+// OrphanedTransfer is a transfer that references a user that no longer exists
+type OrphanedTransfer struct {
+	ID             int    `json:"id"`
+	IdemKey        string `json:"idemKey"`
+	FromUserID     int    `json:"fromUserId"`
+	ToUserID       int    `json:"toUserId"`
+	MissingUserIDs []int  `json:"missingUserIds"`
+}
+
+// OrphanedLedgerEntry is a point_ledger row that references a missing user or transfer
+type OrphanedLedgerEntry struct {
+	ID              int    `json:"id"`
+	UserID          int    `json:"userId"`
+	TransferID      *int   `json:"transferId,omitempty"`
+	MissingUser     bool   `json:"missingUser"`
+	MissingTransfer bool   `json:"missingTransfer"`
+	EventType       string `json:"eventType"`
+	Change          int    `json:"change"`
+}
+
+// IntegrityReport is the repair report produced by CheckIntegrity
+type IntegrityReport struct {
+	IntegrityErrors      []string              `json:"integrityErrors"`
+	ForeignKeyViolations []ForeignKeyViolation `json:"foreignKeyViolations"`
+	OrphanedTransfers    []OrphanedTransfer    `json:"orphanedTransfers"`
+	OrphanedLedger       []OrphanedLedgerEntry `json:"orphanedLedger"`
+}
+
+// OK reports whether the database passed every check
+func (r *IntegrityReport) OK() bool {
+	return len(r.IntegrityErrors) == 0 && len(r.ForeignKeyViolations) == 0 &&
+		len(r.OrphanedTransfers) == 0 && len(r.OrphanedLedger) == 0
+}
+
+// Summary returns a one-line description of the problems found
+func (r *IntegrityReport) Summary() string {
+	if r.OK() {
+		return "ok"
+	}
+	return fmt.Sprintf("%d integrity errors, %d foreign key violations, %d orphaned transfers, %d orphaned ledger rows",
+		len(r.IntegrityErrors), len(r.ForeignKeyViolations), len(r.OrphanedTransfers), len(r.OrphanedLedger))
+}
+
+// CheckIntegrity runs PRAGMA integrity_check and foreign_key_check and lists
+// orphaned transfers and ledger rows so they can be repaired by hand
+func CheckIntegrity() (*IntegrityReport, error) {
+	report := &IntegrityReport{
+		IntegrityErrors:      []string{},
+		ForeignKeyViolations: []ForeignKeyViolation{},
+		OrphanedTransfers:    []OrphanedTransfer{},
+		OrphanedLedger:       []OrphanedLedgerEntry{},
+	}
+
+	// PRAGMA integrity_check returns a single "ok" row when the file is healthy
+	rows, err := DB.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity_check: %v", err)
+	}
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if result != "ok" {
+			report.IntegrityErrors = append(report.IntegrityErrors, result)
+		}
+	}
+	rows.Close()
+
+	rows, err = DB.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run foreign_key_check: %v", err)
+	}
+	for rows.Next() {
+		var v ForeignKeyViolation
+		var fkid int
+		if err := rows.Scan(&v.Table, &v.RowID, &v.Parent, &fkid); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.ForeignKeyViolations = append(report.ForeignKeyViolations, v)
+	}
+	rows.Close()
+
+	// Transfers whose sender or receiver row is gone
+	rows, err = DB.Query(`
+		SELECT t.id, t.idempotency_key, t.from_user_id, t.to_user_id,
+		       CASE WHEN f.id IS NULL THEN 1 ELSE 0 END, CASE WHEN r.id IS NULL THEN 1 ELSE 0 END
+		FROM transfers t
+		LEFT JOIN users f ON f.id = t.from_user_id
+		LEFT JOIN users r ON r.id = t.to_user_id
+		WHERE f.id IS NULL OR r.id IS NULL
+		ORDER BY t.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphaned transfers: %v", err)
+	}
+	for rows.Next() {
+		var t OrphanedTransfer
+		var missingFrom, missingTo bool
+		if err := rows.Scan(&t.ID, &t.IdemKey, &t.FromUserID, &t.ToUserID, &missingFrom, &missingTo); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t.MissingUserIDs = []int{}
+		if missingFrom {
+			t.MissingUserIDs = append(t.MissingUserIDs, t.FromUserID)
+		}
+		if missingTo {
+			t.MissingUserIDs = append(t.MissingUserIDs, t.ToUserID)
+		}
+		report.OrphanedTransfers = append(report.OrphanedTransfers, t)
+	}
+	rows.Close()
+
+	// Ledger rows whose user or transfer row is gone
+	rows, err = DB.Query(`
+		SELECT l.id, l.user_id, l.transfer_id, l.event_type, l.change,
+		       CASE WHEN u.id IS NULL THEN 1 ELSE 0 END,
+		       CASE WHEN l.transfer_id IS NOT NULL AND t.id IS NULL THEN 1 ELSE 0 END
+		FROM point_ledger l
+		LEFT JOIN users u ON u.id = l.user_id
+		LEFT JOIN transfers t ON t.id = l.transfer_id
+		WHERE u.id IS NULL OR (l.transfer_id IS NOT NULL AND t.id IS NULL)
+		ORDER BY l.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphaned ledger rows: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l OrphanedLedgerEntry
+		if err := rows.Scan(&l.ID, &l.UserID, &l.TransferID, &l.EventType, &l.Change, &l.MissingUser, &l.MissingTransfer); err != nil {
+			return nil, err
+		}
+		report.OrphanedLedger = append(report.OrphanedLedger, l)
+	}
+
+	return report, rows.Err()
+}
+
+// Lines formats the report as human readable lines for the startup log
+func (r *IntegrityReport) Lines() []string {
+	lines := []string{}
+	for _, e := range r.IntegrityErrors {
+		lines = append(lines, "integrity_check: "+e)
+	}
+	for _, v := range r.ForeignKeyViolations {
+		lines = append(lines, fmt.Sprintf("foreign_key_check: %s rowid=%d references missing %s", v.Table, v.RowID, v.Parent))
+	}
+	for _, t := range r.OrphanedTransfers {
+		ids := make([]string, len(t.MissingUserIDs))
+		for i, id := range t.MissingUserIDs {
+			ids[i] = fmt.Sprint(id)
+		}
+		lines = append(lines, fmt.Sprintf("orphaned transfer id=%d idemKey=%s missing users=%s", t.ID, t.IdemKey, strings.Join(ids, ",")))
+	}
+	for _, l := range r.OrphanedLedger {
+		lines = append(lines, fmt.Sprintf("orphaned ledger id=%d user=%d missingUser=%t missingTransfer=%t", l.ID, l.UserID, l.MissingUser, l.MissingTransfer))
+	}
+	return lines
+}
@@ -0,0 +1,14 @@
+//go:build sqlite_fts5
+
+package migrations
+
+import "embed"
+
+// FullTextSearch reports whether the binary was built with the sqlite_fts5
+// tag and carries the migrations of the users_fts search index
+const FullTextSearch = true
+
+// searchFiles are the SQLite migrations that need FTS5
+//
+//go:embed sqlite_fts5/*.sql
+var searchFiles embed.FS
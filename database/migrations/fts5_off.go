@@ -0,0 +1,12 @@
+//go:build !sqlite_fts5
+
+package migrations
+
+import "embed"
+
+// FullTextSearch reports whether the binary was built with the sqlite_fts5
+// tag and carries the migrations of the users_fts search index
+const FullTextSearch = false
+
+// searchFiles is empty, SQLite without FTS5 cannot create users_fts
+var searchFiles embed.FS
@@ -0,0 +1,395 @@
+// Package migrations applies the numbered schema migrations embedded in the
+// binary and records them in the schema_migrations table.
+//
+// Migration files live in one directory per dialect and are named
+// NNNN_name.up.sql and NNNN_name.down.sql. Each migration runs in its own
+// transaction together with its schema_migrations row. SQLite migrations
+// that need FTS5 live in sqlite_fts5 and are only embedded by binaries built
+// with the sqlite_fts5 tag.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sqlite/*.sql postgres/*.sql
+var files embed.FS
+
+// Dialect selects the migration directory and the SQL placeholder style
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// ErrSchemaTooNew is returned when the database has migrations applied that
+// this binary does not know about, usually because a newer release ran first
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// ErrIrreversible is returned by Down for a migration without a down script
+var ErrIrreversible = errors.New("irreversible migration")
+
+// Migration is one numbered schema change with its rollback
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator runs the embedded migrations of one dialect against a database
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New loads the embedded migrations for the dialect
+func New(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := load(files, string(dialect))
+	if err != nil {
+		return nil, err
+	}
+	if dialect == SQLite && FullTextSearch {
+		search, err := load(searchFiles, "sqlite_fts5")
+		if err != nil {
+			return nil, err
+		}
+		for _, migration := range search {
+			for _, other := range migrations {
+				if other.Version == migration.Version {
+					return nil, fmt.Errorf("migration %04d is defined twice: %q and %q", migration.Version, other.Name, migration.Name)
+				}
+			}
+		}
+		migrations = append(migrations, search...)
+		sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Latest returns the highest version embedded in the binary
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Current returns the highest version applied to the database, or 0
+func (m *Migrator) Current(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// CheckVersion refuses databases that have migrations this binary does not know
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for version := range applied {
+		if m.find(version) == nil {
+			current, _ := m.Current(ctx)
+			return fmt.Errorf("%w: database is at version %d, this binary knows up to %d", ErrSchemaTooNew, current, m.Latest())
+		}
+	}
+	return nil
+}
+
+// Up applies every pending migration in order and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.CheckVersion(ctx); err != nil {
+		return nil, err
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.run(ctx, migration.Up,
+			m.bind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+			migration.Version, migration.Name, m.timestamp(time.Now().UTC()))
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s failed: %v", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the latest applied migrations, newest first. It stops with
+// ErrIrreversible at a migration whose down script is missing or holds only
+// comments.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.CheckVersion(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if isEmptyScript(migration.Down) {
+			return done, fmt.Errorf("%w: %04d_%s has no down script", ErrIrreversible, migration.Version, migration.Name)
+		}
+		err := m.run(ctx, migration.Down,
+			m.bind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("rollback of %04d_%s failed: %v", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Status lists every embedded migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if at, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Helper function to run a migration script and its bookkeeping statement in one transaction
+func (m *Migrator) run(ctx context.Context, script, bookkeeping string, args ...interface{}) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Helper function to create schema_migrations before the first migration runs
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	appliedAtType := "TEXT"
+	if m.dialect == Postgres {
+		appliedAtType = "TIMESTAMPTZ"
+	}
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at `+appliedAtType+` NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+	return nil
+}
+
+// Helper function to check for schema_migrations without creating it
+func (m *Migrator) tableExists(ctx context.Context) (bool, error) {
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'"
+	if m.dialect == Postgres {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'"
+	}
+	var count int
+	if err := m.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to inspect schema: %v", err)
+	}
+	return count > 0, nil
+}
+
+// Helper function to read the applied versions. A database without
+// schema_migrations has none applied; reading never writes.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	exists, err := m.tableExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := map[int]time.Time{}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if m.dialect == Postgres {
+			err = rows.Scan(&version, &appliedAt)
+		} else {
+			var raw string
+			if err = rows.Scan(&version, &raw); err == nil {
+				appliedAt, err = time.Parse(time.RFC3339, raw)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+		}
+		applied[version] = appliedAt.UTC()
+	}
+	return applied, rows.Err()
+}
+
+// Helper function to store applied_at as RFC3339 text in SQLite, like the other tables
+func (m *Migrator) timestamp(t time.Time) interface{} {
+	if m.dialect == Postgres {
+		return t
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Helper function to rewrite ? placeholders as $n for PostgreSQL
+func (m *Migrator) bind(query string) string {
+	if m.dialect != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Helper function to check whether a script has no statements, only blank lines and -- comments
+func isEmptyScript(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// Helper function to read NNNN_name.up.sql / NNNN_name.down.sql pairs from a directory of fsys
+func load(fsys embed.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unknown migration dialect %q", dir)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+
+		content, err := fsys.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: label}
+			byVersion[version] = migration
+		} else if migration.Name != label {
+			return nil, fmt.Errorf("migration %04d has two names: %q and %q", version, migration.Name, label)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
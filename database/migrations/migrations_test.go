@@ -0,0 +1,161 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Helper function to open an empty SQLite database in a temp directory
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrations.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Helper function to fail unless exactly the given versions are applied
+func expectApplied(t *testing.T, m *Migrator, versions ...int) {
+	t.Helper()
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	want := map[int]bool{}
+	for _, version := range versions {
+		want[version] = true
+	}
+	for _, status := range statuses {
+		if status.Applied != want[status.Version] {
+			t.Errorf("%04d_%s applied is %v, want %v", status.Version, status.Name, status.Applied, want[status.Version])
+		}
+		if status.Applied && status.AppliedAt == nil {
+			t.Errorf("%04d_%s has no applied_at", status.Version, status.Name)
+		}
+	}
+}
+
+// Helper function to check whether a table exists
+func hasTable(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count); err != nil {
+		t.Fatalf("inspect schema: %v", err)
+	}
+	return count > 0
+}
+
+func TestUpDownRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m, err := New(db, SQLite)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	var all []int
+	for _, migration := range m.migrations {
+		all = append(all, migration.Version)
+	}
+	done, err := m.Up(ctx)
+	if err != nil || len(done) != len(all) {
+		t.Fatalf("up applied %d of %d: %v", len(done), len(all), err)
+	}
+	expectApplied(t, m, all...)
+	if current, _ := m.Current(ctx); current != m.Latest() {
+		t.Errorf("current is %d, want %d", current, m.Latest())
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 0 {
+		t.Errorf("second up applied %d: %v", len(done), err)
+	}
+
+	// One step back rolls back the newest migration only
+	done, err = m.Down(ctx, 1)
+	if err != nil || len(done) != 1 || done[0].Version != m.Latest() {
+		t.Fatalf("down 1 rolled back %+v: %v", done, err)
+	}
+	expectApplied(t, m, all[:len(all)-1]...)
+
+	done, err = m.Down(ctx, len(all))
+	if err != nil || len(done) != len(all)-1 {
+		t.Fatalf("down all rolled back %d: %v", len(done), err)
+	}
+	expectApplied(t, m)
+	for _, table := range []string{"users", "api_keys", "audit_log"} {
+		if hasTable(t, db, table) {
+			t.Errorf("%s survived the rollback", table)
+		}
+	}
+
+	// The down scripts leave a schema the up scripts can rebuild
+	if done, err := m.Up(ctx); err != nil || len(done) != len(all) {
+		t.Fatalf("up after down applied %d: %v", len(done), err)
+	}
+	expectApplied(t, m, all...)
+}
+
+func TestCheckVersionRefusesNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m, err := New(db, SQLite)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := m.CheckVersion(ctx); err != nil {
+		t.Fatalf("check of an up to date schema: %v", err)
+	}
+
+	// A newer release applied a migration this binary has never heard of
+	newer := m.Latest() + 1
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from_the_future', '2026-10-19T12:00:00Z')", newer); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if err := m.CheckVersion(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("check got %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("up got %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("down got %v, want ErrSchemaTooNew", err)
+	}
+	if current, _ := m.Current(ctx); current != newer {
+		t.Errorf("current is %d, want %d", current, newer)
+	}
+}
+
+func TestDownRefusesIrreversibleMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := &Migrator{db: db, dialect: SQLite, migrations: []Migration{
+		{Version: 1, Name: "widgets", Up: "CREATE TABLE widgets (id INTEGER PRIMARY KEY)", Down: "DROP TABLE widgets"},
+		{Version: 2, Name: "backfill", Up: "INSERT INTO widgets (id) VALUES (1)", Down: "  \n-- data cannot be restored\n"},
+	}}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	done, err := m.Down(ctx, 2)
+	if !errors.Is(err, ErrIrreversible) || len(done) != 0 {
+		t.Fatalf("down rolled back %+v: %v, want irreversible migration", done, err)
+	}
+	if err.Error() != "irreversible migration: 0002_backfill has no down script" {
+		t.Errorf("error is %q", err)
+	}
+	// The migration stays recorded and nothing older is rolled back past it
+	expectApplied(t, m, 1, 2)
+	if !hasTable(t, db, "widgets") {
+		t.Error("widgets was dropped")
+	}
+}
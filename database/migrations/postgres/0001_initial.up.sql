@@ -0,0 +1,77 @@
+-- Initial PostgreSQL schema, equivalent to sqlite/0001_initial.up.sql
+-- with TIMESTAMPTZ timestamps and BIGSERIAL keys.
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGSERIAL PRIMARY KEY,
+	membership_id TEXT UNIQUE NOT NULL,
+	first_name TEXT NOT NULL,
+	last_name TEXT NOT NULL,
+	phone_number TEXT NOT NULL,
+	email TEXT UNIQUE NOT NULL,
+	membership_level TEXT NOT NULL DEFAULT 'Bronze',
+	points INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active','frozen','suspended','closed')),
+	closed_at TIMESTAMPTZ,
+	joined_date TIMESTAMPTZ NOT NULL DEFAULT now(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS user_status_history (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	from_status TEXT NOT NULL,
+	to_status TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_user ON user_status_history(user_id);
+
+CREATE TABLE IF NOT EXISTS transfers (
+	id BIGSERIAL PRIMARY KEY,
+	from_user_id BIGINT NOT NULL REFERENCES users(id),
+	to_user_id BIGINT NOT NULL REFERENCES users(id),
+	amount INTEGER NOT NULL CHECK (amount > 0),
+	status TEXT NOT NULL CHECK (status IN ('pending','processing','completed','failed','cancelled','reversed')),
+	note TEXT,
+	idempotency_key TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	completed_at TIMESTAMPTZ,
+	fail_reason TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_from ON transfers(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_to ON transfers(to_user_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_created ON transfers(created_at);
+
+CREATE TABLE IF NOT EXISTS point_ledger (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	change INTEGER NOT NULL,
+	balance_after INTEGER NOT NULL,
+	event_type TEXT NOT NULL CHECK (event_type IN ('transfer_out','transfer_in','adjust','earn','redeem')),
+	transfer_id BIGINT REFERENCES transfers(id),
+	reference TEXT,
+	metadata TEXT,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_user ON point_ledger(user_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transfer ON point_ledger(transfer_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_created ON point_ledger(created_at);
+
+CREATE TABLE IF NOT EXISTS transfer_reviews (
+	id BIGSERIAL PRIMARY KEY,
+	transfer_id BIGINT NOT NULL UNIQUE REFERENCES transfers(id),
+	rule TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('open','approved','rejected')),
+	resolution_note TEXT,
+	created_at TIMESTAMPTZ NOT NULL,
+	resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_status ON transfer_reviews(status);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_ledger_partner_reference;
+DROP TABLE IF EXISTS api_key_nonces;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,23 @@
+-- Partner API keys, equivalent to sqlite/0002_partner_api_keys.up.sql
+
+CREATE TABLE api_keys (
+	id BIGSERIAL PRIMARY KEY,
+	key_id TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	secret_hash TEXT NOT NULL,
+	scopes TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	rotated_at TIMESTAMPTZ,
+	revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE api_key_nonces (
+	api_key_id BIGINT NOT NULL REFERENCES api_keys(id),
+	nonce TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (api_key_id, nonce)
+);
+
+CREATE INDEX idx_api_key_nonces_created ON api_key_nonces(created_at);
+
+CREATE UNIQUE INDEX idx_ledger_partner_reference ON point_ledger(reference) WHERE event_type IN ('earn','redeem');
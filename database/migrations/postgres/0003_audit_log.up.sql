@@ -0,0 +1,18 @@
+-- Audit log, equivalent to sqlite/0003_audit_log.up.sql
+
+CREATE TABLE audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	actor TEXT NOT NULL,
+	actor_role TEXT NOT NULL,
+	action TEXT NOT NULL,
+	entity_type TEXT NOT NULL,
+	entity_id BIGINT NOT NULL,
+	changes TEXT,
+	request_id TEXT,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_audit_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_actor ON audit_log(actor);
+CREATE INDEX idx_audit_action ON audit_log(action);
+CREATE INDEX idx_audit_created ON audit_log(created_at);
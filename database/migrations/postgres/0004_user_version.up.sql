@@ -0,0 +1,3 @@
+-- User row version, equivalent to sqlite/0004_user_version.up.sql
+
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+UPDATE api_keys SET revoked_at = now() WHERE revoked_at IS NULL;
+
+ALTER TABLE api_keys RENAME COLUMN secret_salt TO secret_hash;
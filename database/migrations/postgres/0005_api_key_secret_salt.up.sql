@@ -0,0 +1,5 @@
+-- Derived API key secrets, equivalent to sqlite/0005_api_key_secret_salt.up.sql
+
+ALTER TABLE api_keys RENAME COLUMN secret_hash TO secret_salt;
+
+UPDATE api_keys SET revoked_at = now() WHERE revoked_at IS NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS transfer_reviews;
+DROP TABLE IF EXISTS point_ledger;
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS user_status_history;
+DROP TABLE IF EXISTS users;
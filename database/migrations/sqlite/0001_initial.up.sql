@@ -0,0 +1,84 @@
+-- Schema as of the first versioned release. IF NOT EXISTS lets databases
+-- created before migrations existed be stamped at version 1.
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	membership_id TEXT UNIQUE NOT NULL,
+	first_name TEXT NOT NULL,
+	last_name TEXT NOT NULL,
+	phone_number TEXT NOT NULL,
+	email TEXT UNIQUE NOT NULL,
+	membership_level TEXT DEFAULT 'Bronze',
+	points INTEGER DEFAULT 0,
+	status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active','frozen','suspended','closed')),
+	closed_at DATETIME,
+	joined_date DATETIME DEFAULT CURRENT_TIMESTAMP,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_status_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	from_status TEXT NOT NULL,
+	to_status TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_user ON user_status_history(user_id);
+
+CREATE TABLE IF NOT EXISTS transfers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	from_user_id INTEGER NOT NULL,
+	to_user_id INTEGER NOT NULL,
+	amount INTEGER NOT NULL CHECK (amount > 0),
+	status TEXT NOT NULL CHECK (status IN ('pending','processing','completed','failed','cancelled','reversed')),
+	note TEXT,
+	idempotency_key TEXT NOT NULL UNIQUE,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	completed_at TEXT,
+	fail_reason TEXT,
+	FOREIGN KEY (from_user_id) REFERENCES users(id),
+	FOREIGN KEY (to_user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_from ON transfers(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_to ON transfers(to_user_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_created ON transfers(created_at);
+CREATE INDEX IF NOT EXISTS idx_transfers_idem_key ON transfers(idempotency_key);
+
+CREATE TABLE IF NOT EXISTS point_ledger (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	change INTEGER NOT NULL,
+	balance_after INTEGER NOT NULL,
+	event_type TEXT NOT NULL CHECK (event_type IN ('transfer_out','transfer_in','adjust','earn','redeem')),
+	transfer_id INTEGER,
+	reference TEXT,
+	metadata TEXT,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id),
+	FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_user ON point_ledger(user_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transfer ON point_ledger(transfer_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_created ON point_ledger(created_at);
+
+CREATE TABLE IF NOT EXISTS transfer_reviews (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	transfer_id INTEGER NOT NULL UNIQUE,
+	rule TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('open','approved','rejected')),
+	resolution_note TEXT,
+	created_at TEXT NOT NULL,
+	resolved_at TEXT,
+	FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_status ON transfer_reviews(status);
@@ -0,0 +1,27 @@
+-- Partner API keys for the signed earn/redeem API. Only the SHA-256 of a
+-- key's secret is stored; scopes are a comma-separated list.
+
+CREATE TABLE api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	key_id TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	secret_hash TEXT NOT NULL,
+	scopes TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	rotated_at TEXT,
+	revoked_at TEXT
+);
+
+-- Nonces of signed requests, kept while their timestamp is still accepted
+CREATE TABLE api_key_nonces (
+	api_key_id INTEGER NOT NULL,
+	nonce TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (api_key_id, nonce),
+	FOREIGN KEY (api_key_id) REFERENCES api_keys(id)
+);
+
+CREATE INDEX idx_api_key_nonces_created ON api_key_nonces(created_at);
+
+-- A partner reference can be credited or debited only once
+CREATE UNIQUE INDEX idx_ledger_partner_reference ON point_ledger(reference) WHERE event_type IN ('earn','redeem');
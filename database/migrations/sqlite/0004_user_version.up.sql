@@ -0,0 +1,4 @@
+-- Row version for optimistic concurrency, bumped by every write to a user and
+-- returned as the ETag of GET/PUT /users/:id
+
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Salts are not secret hashes, so no key survives the way back
+UPDATE api_keys SET revoked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE revoked_at IS NULL;
+
+ALTER TABLE api_keys RENAME COLUMN secret_salt TO secret_hash;
@@ -0,0 +1,7 @@
+-- API key secrets are derived from a per-key salt and the server's pepper
+-- instead of being stored as a hash that doubled as the signing key. Keys
+-- issued before cannot be converted and are revoked; rotate or recreate them.
+
+ALTER TABLE api_keys RENAME COLUMN secret_hash TO secret_salt;
+
+UPDATE api_keys SET revoked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE revoked_at IS NULL;
@@ -0,0 +1,38 @@
+-- Full-text index for GET /users/search. The trigram tokenizer matches any
+-- three characters, so partial Thai names and phone fragments are found
+-- without word segmentation. phone_digits holds the phone number without
+-- separators, so 0812345678 finds 081-234-5678.
+--
+-- Needs SQLite compiled with FTS5, so this file is only embedded in binaries
+-- built with the sqlite_fts5 tag. Older binaries created the index outside
+-- the migrations; it is dropped and rebuilt here.
+
+DROP TRIGGER IF EXISTS users_fts_insert;
+DROP TRIGGER IF EXISTS users_fts_update;
+DROP TRIGGER IF EXISTS users_fts_delete;
+DROP TABLE IF EXISTS users_fts;
+
+CREATE VIRTUAL TABLE users_fts USING fts5(
+	first_name, last_name, email, phone_number, phone_digits, membership_id,
+	tokenize = 'trigram'
+);
+
+CREATE TRIGGER users_fts_insert AFTER INSERT ON users BEGIN
+	INSERT INTO users_fts (rowid, first_name, last_name, email, phone_number, phone_digits, membership_id)
+	VALUES (new.id, new.first_name, new.last_name, new.email, new.phone_number,
+		replace(replace(new.phone_number, '-', ''), ' ', ''), new.membership_id);
+END;
+
+CREATE TRIGGER users_fts_update AFTER UPDATE OF first_name, last_name, email, phone_number, membership_id ON users BEGIN
+	DELETE FROM users_fts WHERE rowid = old.id;
+	INSERT INTO users_fts (rowid, first_name, last_name, email, phone_number, phone_digits, membership_id)
+	VALUES (new.id, new.first_name, new.last_name, new.email, new.phone_number,
+		replace(replace(new.phone_number, '-', ''), ' ', ''), new.membership_id);
+END;
+
+CREATE TRIGGER users_fts_delete AFTER DELETE ON users BEGIN
+	DELETE FROM users_fts WHERE rowid = old.id;
+END;
+
+INSERT INTO users_fts (rowid, first_name, last_name, email, phone_number, phone_digits, membership_id)
+SELECT id, first_name, last_name, email, phone_number, replace(replace(phone_number, '-', ''), ' ', ''), membership_id FROM users;
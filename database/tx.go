@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// beginRetries is how many times Begin retries when SQLite reports the
+// database as busy after busy_timeout has already elapsed
+const beginRetries = 5
+
+// Begin starts a write transaction on db. The DSN sets _txlock=immediate so
+// the write lock is taken up front, which serializes balance updates and makes
+// SQLITE_BUSY surface here rather than halfway through a transfer.
+func Begin(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	backoff := 50 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err == nil || !IsBusy(err) || attempt >= beginRetries {
+			return tx, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// IsBusy reports whether err is SQLITE_BUSY or SQLITE_LOCKED
+func IsBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// IsUniqueViolation reports whether err is a UNIQUE constraint failure
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
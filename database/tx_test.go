@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Helper function to open a connection pool on the file that fails at once
+// with SQLITE_BUSY (busy_timeout 0) so Begin has to retry itself
+func openNoWait(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_txlock=immediate&_busy_timeout=0")
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Helper function to take the write lock on the file from another pool
+func holdWriteLock(t *testing.T, path string) *sql.Tx {
+	t.Helper()
+	tx, err := openNoWait(t, path).Begin()
+	if err != nil {
+		t.Fatalf("take write lock: %v", err)
+	}
+	return tx
+}
+
+func TestBeginRetriesWhileBusy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	holder := holdWriteLock(t, path)
+	go func() {
+		time.Sleep(120 * time.Millisecond)
+		holder.Rollback()
+	}()
+
+	started := time.Now()
+	tx, err := Begin(context.Background(), openNoWait(t, path))
+	if err != nil {
+		t.Fatalf("Begin should retry until the lock is released: %v", err)
+	}
+	defer tx.Rollback()
+	if waited := time.Since(started); waited < 100*time.Millisecond {
+		t.Errorf("Begin returned after %s, before the lock was released", waited)
+	}
+}
+
+func TestBeginGivesUpWhenStillBusy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	holder := holdWriteLock(t, path)
+	defer holder.Rollback()
+
+	_, err := Begin(context.Background(), openNoWait(t, path))
+	if !IsBusy(err) {
+		t.Fatalf("Begin returned %v, want SQLITE_BUSY after %d retries", err, beginRetries)
+	}
+}
+
+func TestBeginStopsOnCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	holder := holdWriteLock(t, path)
+	defer holder.Rollback()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel()
+	if _, err := Begin(ctx, openNoWait(t, path)); err != context.DeadlineExceeded {
+		t.Fatalf("Begin returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"temp-kbtg-backend/audit"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ChangeUserStatus godoc
+// @Summary Change account status
+// @Description เปลี่ยนสถานะบัญชี (active, frozen, suspended, closed) พร้อมบันทึกเหตุผล ผู้ดำเนินการคือ sub ของ token การปิดบัญชี (closed) ทำได้เฉพาะ admin
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param status body models.UserStatusChangeRequest true "New status"
+// @Success 200 {object} map[string]interface{} "success, message, data"
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 409 {object} problem.Problem "Transition not allowed"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/users/{id}/status [put]
+func (h *Handler) ChangeUserStatus(c *fiber.Ctx) error {
+	id := paramID(c)
+
+	var req models.UserStatusChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(err)
+	}
+
+	// Blank reasons count as missing
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := validateRequest(&req); err != nil {
+		return err
+	}
+
+	// Closing forfeits the points, so it needs the same role as DELETE /users/:id
+	if req.Status == models.UserClosed {
+		if err := authorizeClose(c); err != nil {
+			return err
+		}
+	}
+
+	ctx := c.UserContext()
+	actor, _ := audit.Actor(ctx)
+	var change models.UserStatusChange
+	err := h.store.WithinTx(ctx, func(tx repository.Store) error {
+		// Check if user exists
+		user, err := getMember(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !user.Status.CanTransitionTo(req.Status) {
+			return problem.New(fiber.StatusConflict, "INVALID_TRANSITION",
+				fmt.Sprintf("Cannot change status from %s to %s", user.Status, req.Status))
+		}
+
+		// Closing an account also forfeits its remaining points
+		now := time.Now().UTC()
+		if req.Status == models.UserClosed {
+			_, err = closeUser(ctx, tx, user, now)
+		} else {
+			err = tx.Users().SetStatus(ctx, user.ID, req.Status, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Record the transition
+		change = models.UserStatusChange{
+			UserID:     user.ID,
+			FromStatus: user.Status,
+			ToStatus:   req.Status,
+			Reason:     req.Reason,
+			Actor:      actor,
+			CreatedAt:  now,
+		}
+		if err = tx.Users().AddStatusChange(ctx, &change); err != nil {
+			return err
+		}
+
+		action := models.AuditUserStatusChange
+		if req.Status == models.UserClosed {
+			action = models.AuditUserClose
+		}
+		return auditUser(ctx, tx, action, &user, user.ID)
+	})
+	if err != nil {
+		return toProblem(err, "Failed to update user status")
+	}
+
+	slog.InfoContext(ctx, "user status changed",
+		"user_id", change.UserID,
+		"from", change.FromStatus,
+		"to", change.ToStatus,
+		"actor", change.Actor,
+	)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User status updated successfully",
+		"data":    change,
+	})
+}
+
+// GetUserStatusHistory godoc
+// @Summary Get account status history
+// @Description ดูประวัติการเปลี่ยนสถานะบัญชี
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "success, data, total"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/users/{id}/status-history [get]
+func (h *Handler) GetUserStatusHistory(c *fiber.Ctx) error {
+	id := paramID(c)
+	ctx := c.UserContext()
+
+	// Check if user exists
+	if _, err := h.store.Users().GetByID(ctx, id); errors.Is(err, repository.ErrNotFound) {
+		return errUserNotFound()
+	} else if err != nil {
+		return problem.Internal("Failed to fetch user", err)
+	}
+
+	history, err := h.store.Users().ListStatusChanges(ctx, id)
+	if err != nil {
+		return problem.Internal("Failed to fetch status history", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    history,
+		"total":   len(history),
+	})
+}
+
+// Helper function to close an account: forfeits the remaining points to the
+// system account through the ledger and marks the user closed.
+// It returns the number of forfeited points.
+func closeUser(ctx context.Context, tx repository.Store, user models.User, now time.Time) (int, error) {
+	points := user.Points
+
+	if points > 0 {
+		system, err := tx.Users().GetByMembershipID(ctx, models.SystemMembershipID)
+		if err != nil {
+			return 0, fmt.Errorf("system account not found: %v", err)
+		}
+
+		balance, err := tx.Users().AdjustPoints(ctx, user.ID, -points)
+		if err != nil {
+			return 0, err
+		}
+		systemBalance, err := tx.Users().AdjustPoints(ctx, system.ID, points)
+		if err != nil {
+			return 0, err
+		}
+
+		// Record in ledger - closed account (debit) and system account (credit)
+		reference := "account_closure:" + user.MembershipID
+		err = tx.Ledger().Append(ctx,
+			models.PointLedger{UserID: user.ID, Change: -points, BalanceAfter: balance, EventType: models.EventAdjust, Reference: &reference, CreatedAt: now},
+			models.PointLedger{UserID: system.ID, Change: points, BalanceAfter: systemBalance, EventType: models.EventAdjust, Reference: &reference, CreatedAt: now},
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Users().SetStatus(ctx, user.ID, models.UserClosed, now); err != nil {
+		return 0, err
+	}
+
+	return points, nil
+}
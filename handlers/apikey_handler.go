@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"temp-kbtg-backend/audit"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateAPIKey godoc
+// @Summary Create a partner API key
+// @Description สร้าง API key สำหรับร้านค้าพาร์ทเนอร์ พร้อมกำหนด scope (earn, redeem, read-balance) secret จะแสดงเพียงครั้งเดียว ระบบไม่เก็บ secret แต่คำนวณจาก salt สุ่มของ key กับ pepper ใน config ของ server
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param apiKey body models.APIKeyCreateRequest true "Key name and scopes"
+// @Success 201 {object} models.APIKeySecretResponse
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (h *Handler) CreateAPIKey(c *fiber.Ctx) error {
+	var req models.APIKeyCreateRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	keyID, salt, err := auth.NewAPIKey()
+	if err != nil {
+		return problem.Internal("Failed to generate API key", err)
+	}
+	key := models.APIKey{
+		KeyID:      keyID,
+		Name:       req.Name,
+		SecretSalt: salt,
+		Scopes:     uniqueScopes(req.Scopes),
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	ctx := c.UserContext()
+	err = h.store.WithinTx(ctx, func(tx repository.Store) error {
+		if err := tx.APIKeys().Create(ctx, &key); err != nil {
+			return err
+		}
+		return auditAPIKey(ctx, tx, models.AuditAPIKeyCreate, nil, key)
+	})
+	if err != nil {
+		return toProblem(err, "Failed to create API key")
+	}
+
+	logAPIKey(c, "api key created", key)
+	return c.Status(fiber.StatusCreated).JSON(models.APIKeySecretResponse{APIKey: key, Secret: h.auth.APIKeySecret(key.KeyID, salt)})
+}
+
+// GetAPIKeys godoc
+// @Summary List partner API keys
+// @Description ดูรายการ API key ทั้งหมด รวมถึงที่ถูกยกเลิกแล้ว (ไม่แสดง secret)
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIKeyListResponse
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/api-keys [get]
+func (h *Handler) GetAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.store.APIKeys().List(c.UserContext())
+	if err != nil {
+		return problem.Internal("Failed to fetch API keys", err)
+	}
+	return c.JSON(models.APIKeyListResponse{Data: keys})
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate a partner API key
+// @Description ออก secret ใหม่ให้ API key เดิม (keyId ไม่เปลี่ยน) secret เก่าใช้ไม่ได้ทันที
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKeySecretResponse
+// @Failure 404 {object} problem.Problem "API key not found"
+// @Failure 409 {object} problem.Problem "API key is revoked"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/api-keys/{id}/rotate [post]
+func (h *Handler) RotateAPIKey(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	key, err := h.activeAPIKey(c)
+	if err != nil {
+		return err
+	}
+
+	salt, err := auth.NewSalt()
+	if err != nil {
+		return problem.Internal("Failed to generate API key", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	rotated := key
+	rotated.SecretSalt = salt
+	rotated.RotatedAt = &now
+	err = h.store.WithinTx(ctx, func(tx repository.Store) error {
+		err := tx.APIKeys().Rotate(ctx, key.ID, salt, now)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errAPIKeyRevoked()
+		}
+		if err != nil {
+			return err
+		}
+		return auditAPIKey(ctx, tx, models.AuditAPIKeyRotate, &key, rotated)
+	})
+	if err != nil {
+		return toProblem(err, "Failed to rotate API key")
+	}
+
+	key = rotated
+	logAPIKey(c, "api key rotated", key)
+	return c.JSON(models.APIKeySecretResponse{APIKey: key, Secret: h.auth.APIKeySecret(key.KeyID, salt)})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke a partner API key
+// @Description ยกเลิก API key ทันที คำขอที่ลงนามด้วย key นี้จะถูกปฏิเสธ ประวัติใน point ledger ยังคงอยู่
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKey
+// @Failure 404 {object} problem.Problem "API key not found"
+// @Failure 409 {object} problem.Problem "API key is already revoked"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	key, err := h.activeAPIKey(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	revoked := key
+	revoked.RevokedAt = &now
+	err = h.store.WithinTx(ctx, func(tx repository.Store) error {
+		err := tx.APIKeys().Revoke(ctx, key.ID, now)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errAPIKeyRevoked()
+		}
+		if err != nil {
+			return err
+		}
+		return auditAPIKey(ctx, tx, models.AuditAPIKeyRevoke, &key, revoked)
+	})
+	if err != nil {
+		return toProblem(err, "Failed to revoke API key")
+	}
+
+	key = revoked
+	logAPIKey(c, "api key revoked", key)
+	return c.JSON(key)
+}
+
+// Helper function to load the key named in the path, rejecting revoked keys
+func (h *Handler) activeAPIKey(c *fiber.Ctx) (models.APIKey, error) {
+	key, err := h.store.APIKeys().GetByID(c.UserContext(), paramID(c))
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.APIKey{}, problem.New(fiber.StatusNotFound, problem.CodeNotFound, "API key not found")
+	}
+	if err != nil {
+		return models.APIKey{}, problem.Internal("Failed to fetch API key", err)
+	}
+	if key.RevokedAt != nil {
+		return models.APIKey{}, errAPIKeyRevoked()
+	}
+	return key, nil
+}
+
+// Helper function to report a change to a revoked key
+func errAPIKeyRevoked() *problem.Problem {
+	return problem.New(fiber.StatusConflict, "API_KEY_REVOKED", "API key is revoked")
+}
+
+// Helper function to drop repeated scopes, keeping the order they were given in
+func uniqueScopes(scopes []models.APIKeyScope) []models.APIKeyScope {
+	seen := map[models.APIKeyScope]bool{}
+	unique := make([]models.APIKeyScope, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
+
+// Helper function to audit a key management event. The secret salt is not a
+// JSON field, so it never reaches the audit log.
+func auditAPIKey(ctx context.Context, tx repository.Store, action models.AuditAction, before *models.APIKey, after models.APIKey) error {
+	var changes map[string]models.FieldChange
+	var err error
+	if before == nil {
+		changes, err = audit.Diff(nil, after)
+	} else {
+		changes, err = audit.Diff(*before, after)
+	}
+	if err != nil {
+		return err
+	}
+	return audit.Record(ctx, tx, action, models.EntityAPIKey, after.ID, changes)
+}
+
+// Helper function to log a key management event with the admin who made it
+func logAPIKey(c *fiber.Ctx, msg string, key models.APIKey) {
+	ctx := c.UserContext()
+	principal, _ := auth.FromContext(ctx)
+	slog.InfoContext(ctx, msg,
+		"api_key_id", key.ID,
+		"key_id", key.KeyID,
+		"scopes", models.JoinScopes(key.Scopes),
+		"actor", principal.Subject,
+	)
+}
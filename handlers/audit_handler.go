@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"temp-kbtg-backend/audit"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAuditLog godoc
+// @Summary Search the audit log
+// @Description ค้นหาประวัติการเปลี่ยนแปลงข้อมูล ว่าใครทำอะไรกับข้อมูลใด พร้อมค่าก่อนและหลังของแต่ละฟิลด์ เรียงจากใหม่ไปเก่า
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param actor query string false "Token subject or partner key ID"
+// @Param action query string false "Action, e.g. user.update"
+// @Param entityType query string false "Entity type (user/transfer/transfer_review/api_key)"
+// @Param entityId query int false "Entity ID"
+// @Param requestId query string false "Request ID"
+// @Param from query string false "Earliest time (RFC 3339)"
+// @Param to query string false "Latest time (RFC 3339)"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Success 200 {object} models.AuditListResponse
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/audit [get]
+func (h *Handler) GetAuditLog(c *fiber.Ctx) error {
+	filter := repository.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     models.AuditAction(c.Query("action")),
+		EntityType: c.Query("entityType"),
+		RequestID:  c.Query("requestId"),
+	}
+
+	var fieldErrors []problem.FieldError
+	if value := c.Query("entityId"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			fieldErrors = append(fieldErrors, problem.FieldError{Field: "entityId", Message: "must be a positive integer"})
+		}
+		filter.EntityID = id
+	}
+	for _, bound := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, problem.FieldError{Field: bound.name, Message: "must be an RFC 3339 timestamp"})
+		}
+		*bound.dest = at
+	}
+	if len(fieldErrors) > 0 {
+		return problem.Validation("Invalid query parameters", fieldErrors...)
+	}
+
+	page, pageSize := h.pageParams(c)
+	entries, total, err := h.store.Audit().List(c.UserContext(), filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return problem.Internal("Failed to fetch audit log", err)
+	}
+
+	return c.JSON(models.AuditListResponse{
+		Data:     entries,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// Helper function to audit a change to a user, reading the new state inside
+// the same transaction. A nil before records a created user.
+func auditUser(ctx context.Context, tx repository.Store, action models.AuditAction, before *models.User, id int) error {
+	after, err := tx.Users().GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var changes map[string]models.FieldChange
+	if before == nil {
+		changes, err = audit.Diff(nil, after)
+	} else {
+		changes, err = audit.Diff(*before, after)
+	}
+	if err != nil {
+		return err
+	}
+	return audit.Record(ctx, tx, action, models.EntityUser, id, changes)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IssueDevToken godoc
+// @Summary Issue a token (dev only)
+// @Description ออก access token โดยไม่ต้องใช้รหัสผ่าน สำหรับทดสอบเท่านั้น (เปิดด้วย auth.dev_login) สมาชิกต้องระบุ userId ส่วน support/admin ต้องระบุ name
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body models.TokenRequest true "Role and subject"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Router /auth/token [post]
+func (h *Handler) IssueDevToken(c *fiber.Ctx) error {
+	var req models.TokenRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	// Members sign in as an existing account, staff by name
+	subject := req.Name
+	if auth.Role(req.Role) == auth.RoleMember {
+		user, err := h.store.Users().GetByID(c.UserContext(), req.UserID)
+		if errors.Is(err, repository.ErrNotFound) || user.MembershipID == models.SystemMembershipID {
+			return errUserNotFound()
+		}
+		if err != nil {
+			return problem.Internal("Failed to fetch user", err)
+		}
+		subject = strconv.Itoa(user.ID)
+	}
+
+	token, expiresAt, err := h.auth.Issue(subject, auth.Role(req.Role))
+	if err != nil {
+		return problem.Internal("Failed to issue token", err)
+	}
+
+	return c.JSON(models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(expiresAt).Round(time.Second).Seconds()),
+		ExpiresAt:   expiresAt.UTC(),
+	})
+}
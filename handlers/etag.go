@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper function to build the entity tag of a user, which changes with every
+// write to the account
+func userETag(user models.User) string {
+	return `"` + strconv.Itoa(user.Version) + `"`
+}
+
+// Helper function to check an If-Match or If-None-Match header against an
+// entity tag. If-Match compares strongly so weak tags never match, while
+// If-None-Match ignores the W/ prefix.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function to require If-Match on an update, so a client can only
+// change the version of the user it has read
+func requireIfMatch(c *fiber.Ctx) (string, error) {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return "", problem.New(fiber.StatusPreconditionRequired, problem.CodePreconditionReq,
+			"If-Match header is required, send the ETag of the user being updated")
+	}
+	return header, nil
+}
+
+// Helper function to reject an update made against an older version of the user.
+// The current ETag is sent back so the client can fetch and retry.
+func checkIfMatch(c *fiber.Ctx, header string, user models.User) error {
+	if etagMatches(header, userETag(user), false) {
+		return nil
+	}
+	c.Set(fiber.HeaderETag, userETag(user))
+	return problem.New(fiber.StatusPreconditionFailed, problem.CodePreconditionFail,
+		"User was modified by another request, fetch it again and retry")
+}
@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"context"
+	"strconv"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/problem"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetUserHonoursIfNoneMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"no header", "", fiber.StatusOK},
+		{"current tag", `"1"`, fiber.StatusNotModified},
+		{"weak current tag", `W/"1"`, fiber.StatusNotModified},
+		{"any tag", "*", fiber.StatusNotModified},
+		{"current tag in a list", `"7", W/"1"`, fiber.StatusNotModified},
+		{"old tag", `"7"`, fiber.StatusOK},
+		{"weak old tag", `W/"7"`, fiber.StatusOK},
+		{"unquoted tag", `1`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			user := s.createMember(t, "somchai@example.com", 100)
+			token := s.token(t, strconv.Itoa(user.ID), auth.RoleMember)
+
+			res := s.do(t, fiber.MethodGet, "/users/"+strconv.Itoa(user.ID), token,
+				map[string]string{fiber.HeaderIfNoneMatch: tt.ifNoneMatch}, "")
+			if res.status != tt.status {
+				t.Fatalf("got %d, want %d: %v", res.status, tt.status, res.body)
+			}
+			if etag := res.header.Get(fiber.HeaderETag); etag != `"1"` {
+				t.Errorf("ETag is %s, want \"1\"", etag)
+			}
+			if tt.status == fiber.StatusNotModified && res.body != nil {
+				t.Errorf("304 has a body: %v", res.body)
+			}
+			if tt.status == fiber.StatusOK && res.body["data"] == nil {
+				t.Errorf("200 has no user: %v", res.body)
+			}
+		})
+	}
+}
+
+func TestUpdateUserHonoursIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+		code    string
+	}{
+		{"current tag", `"1"`, fiber.StatusOK, ""},
+		{"any tag", "*", fiber.StatusOK, ""},
+		{"current tag in a list", `"7", "1"`, fiber.StatusOK, ""},
+		// If-Match compares strongly, a weak tag never matches
+		{"weak current tag", `W/"1"`, fiber.StatusPreconditionFailed, problem.CodePreconditionFail},
+		{"old tag", `"7"`, fiber.StatusPreconditionFailed, problem.CodePreconditionFail},
+		{"no header", "", fiber.StatusPreconditionRequired, problem.CodePreconditionReq},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			user := s.createMember(t, "somchai@example.com", 100)
+			token := s.token(t, strconv.Itoa(user.ID), auth.RoleMember)
+
+			res := s.updateUser(t, user.ID, token, tt.ifMatch, `{"last_name": "ใจดีมาก"}`)
+			got, _ := s.store.Users().GetByID(context.Background(), user.ID)
+
+			if tt.status == fiber.StatusOK {
+				if res.status != fiber.StatusOK {
+					t.Fatalf("got %d: %v", res.status, res.body)
+				}
+				if etag := res.header.Get(fiber.HeaderETag); etag != `"2"` || got.Version != 2 {
+					t.Errorf("ETag is %s and version %d, want \"2\"", etag, got.Version)
+				}
+				return
+			}
+
+			expectProblem(t, res, tt.status, tt.code)
+			if got.Version != 1 || got.LastName != user.LastName {
+				t.Errorf("user changed to %+v", got)
+			}
+			// A 412 carries the current tag so the client can refetch and retry
+			if tt.status == fiber.StatusPreconditionFailed {
+				if etag := res.header.Get(fiber.HeaderETag); etag != `"1"` {
+					t.Errorf("ETag is %q, want \"1\"", etag)
+				}
+			}
+		})
+	}
+}
+
+func TestIfMatchDetectsLostUpdate(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createMember(t, "somchai@example.com", 100)
+	token := s.token(t, strconv.Itoa(user.ID), auth.RoleMember)
+
+	// Two clients read version 1, the first write wins
+	first := s.updateUser(t, user.ID, token, `"1"`, `{"last_name": "ใจดีมาก"}`)
+	if first.status != fiber.StatusOK {
+		t.Fatalf("first got %d: %v", first.status, first.body)
+	}
+	second := s.updateUser(t, user.ID, token, `"1"`, `{"first_name": "สมศักดิ์"}`)
+	expectProblem(t, second, fiber.StatusPreconditionFailed, problem.CodePreconditionFail)
+	if etag := second.header.Get(fiber.HeaderETag); etag != first.header.Get(fiber.HeaderETag) {
+		t.Errorf("412 sent ETag %s, want %s", etag, first.header.Get(fiber.HeaderETag))
+	}
+
+	// The refetched tag is then accepted
+	retry := s.updateUser(t, user.ID, token, second.header.Get(fiber.HeaderETag), `{"first_name": "สมศักดิ์"}`)
+	if retry.status != fiber.StatusOK {
+		t.Fatalf("retry got %d: %v", retry.status, retry.body)
+	}
+	got, _ := s.store.Users().GetByID(context.Background(), user.ID)
+	if got.FirstName != "สมศักดิ์" || got.LastName != "ใจดีมาก" {
+		t.Errorf("user is %+v", got)
+	}
+}
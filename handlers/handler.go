@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/config"
+	"temp-kbtg-backend/logging"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/rules"
+	"temp-kbtg-backend/service"
+	"temp-kbtg-backend/tracing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves the HTTP API on top of the repositories and services
+type Handler struct {
+	store      repository.Store
+	transfers  *service.TransferService
+	partners   *service.PartnerService
+	auth       *auth.Authenticator
+	pagination config.Pagination
+	readiness  []ReadinessCheck
+}
+
+// New creates the handlers, checking transfers against the given fraud and velocity rules
+func New(store repository.Store, authenticator *auth.Authenticator, pagination config.Pagination, engine *rules.Engine) *Handler {
+	return &Handler{
+		store:      store,
+		transfers:  service.NewTransferService(store, engine),
+		partners:   service.NewPartnerService(store),
+		auth:       authenticator,
+		pagination: pagination,
+	}
+}
+
+// domainErrors maps service errors to an HTTP status and error code
+var domainErrors = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{service.ErrInvalidTransfer, fiber.StatusBadRequest, problem.CodeValidation},
+	{service.ErrSelfTransfer, fiber.StatusUnprocessableEntity, "BUSINESS_RULE_VIOLATION"},
+	{service.ErrUserNotFound, fiber.StatusNotFound, problem.CodeNotFound},
+	{service.ErrInsufficientPoints, fiber.StatusConflict, "INSUFFICIENT_POINTS"},
+	{service.ErrAccountStatus, fiber.StatusUnprocessableEntity, "ACCOUNT_STATUS_VIOLATION"},
+	{service.ErrTransferDenied, fiber.StatusUnprocessableEntity, "TRANSFER_DENIED"},
+	{service.ErrReviewNotFound, fiber.StatusNotFound, problem.CodeNotFound},
+	{service.ErrReviewResolved, fiber.StatusConflict, "REVIEW_ALREADY_RESOLVED"},
+	{service.ErrDuplicateReference, fiber.StatusConflict, "DUPLICATE_REFERENCE"},
+}
+
+// Helper function to turn an error from a service or transaction into a problem.
+// Unknown errors become an internal error with the fallback message, their
+// text is only logged.
+func toProblem(err error, fallback string) *problem.Problem {
+	var p *problem.Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	for _, d := range domainErrors {
+		if errors.Is(err, d.kind) {
+			return problem.New(d.status, d.code, err.Error())
+		}
+	}
+	return problem.Internal(fallback, err)
+}
+
+// Helper function to parse the page and pageSize query parameters, clamping
+// the page size to the configured limits
+func (h *Handler) pageParams(c *fiber.Ctx) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize, _ = strconv.Atoi(c.Query("pageSize"))
+	if pageSize < 1 {
+		pageSize = h.pagination.DefaultPageSize
+	}
+	if pageSize > h.pagination.MaxPageSize {
+		pageSize = h.pagination.MaxPageSize
+	}
+	return page, pageSize
+}
+
+// Helper function to report a request body that could not be parsed
+func invalidBody(err error) *problem.Problem {
+	return problem.New(fiber.StatusBadRequest, problem.CodeInvalidBody, "Invalid request body").WithCause(err)
+}
+
+// ErrorHandler is the Fiber error handler. Every error returned by a handler
+// or raised by Fiber is written as an application/problem+json document
+// carrying the request and trace IDs.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	p := *problem.From(err)
+	ctx := c.UserContext()
+	p.Instance = c.Path()
+	p.RequestID = logging.RequestID(ctx)
+	p.TraceID = tracing.TraceID(ctx)
+	return c.Status(p.Status).JSON(p, problem.ContentType)
+}
+
+// Helper function to check that a member only acts on their own account, i.e.
+// one of userIDs. Staff may act on any account.
+func authorizeUser(c *fiber.Ctx, userIDs ...int) error {
+	principal, ok := auth.FromContext(c.UserContext())
+	if !ok {
+		return auth.Unauthorized(c, "Authentication required")
+	}
+	if principal.IsStaff() {
+		return nil
+	}
+	for _, userID := range userIDs {
+		if principal.UserID == userID {
+			return nil
+		}
+	}
+	return auth.Forbidden("Members may only access their own account")
+}
+
+// Helper function to check that the caller may close accounts. Closing
+// forfeits the remaining points, so DELETE /users/:id and a status change to
+// closed both require an admin.
+func authorizeClose(c *fiber.Ctx) error {
+	principal, ok := auth.FromContext(c.UserContext())
+	if !ok {
+		return auth.Unauthorized(c, "Authentication required")
+	}
+	if principal.Role != auth.RoleAdmin {
+		return auth.Forbidden("Only admins may close accounts")
+	}
+	return nil
+}
+
+// Helper function to read a numeric :id route parameter.
+// Non-numeric ids return 0, which never matches a row, so callers report not found.
+func paramID(c *fiber.Ctx) int {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,138 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/config"
+	"temp-kbtg-backend/handlers"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository/memory"
+	"temp-kbtg-backend/rules"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testServer is the user API of main.go on top of an in-memory store, without rate limits
+type testServer struct {
+	app   *fiber.App
+	store *memory.Store
+	auth  *auth.Authenticator
+
+	members int // Members created so far, numbers their membership IDs
+}
+
+// Helper function to start a test server with an HS256 authenticator
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	authenticator, err := auth.New(config.Auth{
+		Algorithm:    "HS256",
+		Secret:       strings.Repeat("s", config.MinSecretLength),
+		Issuer:       "kbtg-backend-test",
+		TokenTTL:     time.Hour,
+		APIKeyPepper: strings.Repeat("p", config.MinSecretLength),
+	})
+	if err != nil {
+		t.Fatalf("auth: %v", err)
+	}
+
+	store := memory.New()
+	h := handlers.New(store, authenticator, config.Pagination{DefaultPageSize: 10, MaxPageSize: 100}, rules.Default())
+
+	app := fiber.New(fiber.Config{ErrorHandler: handlers.ErrorHandler})
+	app.Use(auth.Middleware(authenticator))
+	anyRole := auth.Require(auth.RoleMember, auth.RoleSupport, auth.RoleAdmin)
+	staff := auth.Require(auth.RoleSupport, auth.RoleAdmin)
+	admin := auth.Require(auth.RoleAdmin)
+	app.Get("/users", staff, h.GetAllUsers)
+	app.Get("/users/:id", anyRole, h.GetUserByID)
+	app.Put("/users/:id", anyRole, h.UpdateUser)
+	app.Patch("/users/:id", anyRole, h.PatchUser)
+	app.Delete("/users/:id", admin, h.DeleteUser)
+
+	return &testServer{app: app, store: store, auth: authenticator}
+}
+
+// Helper function to issue a bearer token. Members are named by their user ID.
+func (s *testServer) token(t *testing.T, subject string, role auth.Role) string {
+	t.Helper()
+	token, _, err := s.auth.Issue(subject, role)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+// Helper function to create an active Bronze member
+func (s *testServer) createMember(t *testing.T, email string, points int) models.User {
+	t.Helper()
+	s.members++
+	user := models.User{
+		MembershipID:    fmt.Sprintf("LBK%06d", 100000+s.members),
+		FirstName:       "สมชาย",
+		LastName:        "ใจดี",
+		PhoneNumber:     "0812345678",
+		Email:           email,
+		MembershipLevel: models.LevelBronze,
+		Points:          points,
+	}
+	if err := s.store.Users().Create(context.Background(), &user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// testResponse is a response with its decoded JSON body
+type testResponse struct {
+	status int
+	header http.Header
+	body   map[string]interface{}
+}
+
+// Helper function to send a request with a bearer token and the given headers
+func (s *testServer) do(t *testing.T, method, path, token string, headers map[string]string, body string) testResponse {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	res := testResponse{status: resp.StatusCode, header: resp.Header}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &res.body); err != nil {
+			t.Fatalf("%s %s: body is not JSON: %s", method, path, raw)
+		}
+	}
+	return res
+}
+
+// Helper function to fail unless a response is a problem with the given status and code
+func expectProblem(t *testing.T, res testResponse, status int, code string) {
+	t.Helper()
+	if res.status != status || res.body["code"] != code {
+		t.Fatalf("got %d %v, want %d %s: %v", res.status, res.body["code"], status, code, res.body)
+	}
+}
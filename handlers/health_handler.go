@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"temp-kbtg-backend/buildinfo"
+	"temp-kbtg-backend/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// readinessTimeout bounds how long /readyz waits for all checks together
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck reports whether one dependency is ready to serve traffic
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// AddReadinessCheck registers a check that /readyz runs on every request
+func (h *Handler) AddReadinessCheck(name string, check func(ctx context.Context) error) {
+	h.readiness = append(h.readiness, ReadinessCheck{Name: name, Check: check})
+}
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description ตรวจว่า process ยังทำงานอยู่ (ไม่ตรวจฐานข้อมูล)
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Router /healthz [get]
+func (h *Handler) Healthz(c *fiber.Ctx) error {
+	return c.JSON(models.HealthResponse{Status: "ok"})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description ตรวจว่าพร้อมรับ request: ฐานข้อมูลตอบสนอง, migrations เป็นเวอร์ชันล่าสุด และ background workers ทำงานอยู่
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.ReadinessResponse
+// @Failure 503 {object} models.ReadinessResponse "Not ready"
+// @Router /readyz [get]
+func (h *Handler) Readyz(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), readinessTimeout)
+	defer cancel()
+
+	response := models.ReadinessResponse{
+		Status: "ready",
+		Checks: make(map[string]models.CheckResult, len(h.readiness)),
+	}
+	for _, check := range h.readiness {
+		if err := check.Check(ctx); err != nil {
+			response.Status = "not_ready"
+			response.Checks[check.Name] = models.CheckResult{Status: "failing", Error: err.Error()}
+			continue
+		}
+		response.Checks[check.Name] = models.CheckResult{Status: "ok"}
+	}
+
+	if response.Status != "ready" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.JSON(response)
+}
+
+// Version godoc
+// @Summary Build information
+// @Description ดูเวอร์ชัน, commit และเวลา build ของ binary ที่ทำงานอยู่
+// @Tags Health
+// @Produce json
+// @Success 200 {object} buildinfo.Info
+// @Router /version [get]
+func (h *Handler) Version(c *fiber.Ctx) error {
+	return c.JSON(buildinfo.Get())
+}
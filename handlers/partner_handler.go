@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PartnerEarn godoc
+// @Summary Earn points (partner)
+// @Description ร้านค้าพาร์ทเนอร์ให้แต้มสมาชิก ต้องใช้ API key ที่มี scope earn และลงนามคำขอด้วย HMAC-SHA256 reference ใช้ได้ครั้งเดียวต่อ key
+// @Tags Partner
+// @Accept json
+// @Produce json
+// @Param X-Api-Key header string true "API key ID"
+// @Param X-Timestamp header string true "Unix seconds"
+// @Param X-Nonce header string true "Unique value per request"
+// @Param X-Signature header string true "Hex HMAC-SHA256 of the canonical request"
+// @Param earn body models.PartnerPointsRequest true "Member, amount and partner reference"
+// @Success 200 {object} models.PartnerPointsResponse
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 401 {object} problem.Problem "Missing, invalid or replayed signature"
+// @Failure 403 {object} problem.Problem "API key lacks the scope"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 409 {object} problem.Problem "Reference already used"
+// @Failure 422 {object} problem.Problem "Account status does not allow the operation"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Router /partner/earn [post]
+func (h *Handler) PartnerEarn(c *fiber.Ctx) error {
+	return h.partnerPoints(c, models.ScopeEarn)
+}
+
+// PartnerRedeem godoc
+// @Summary Redeem points (partner)
+// @Description ร้านค้าพาร์ทเนอร์ตัดแต้มสมาชิก ต้องใช้ API key ที่มี scope redeem และลงนามคำขอด้วย HMAC-SHA256 reference ใช้ได้ครั้งเดียวต่อ key
+// @Tags Partner
+// @Accept json
+// @Produce json
+// @Param X-Api-Key header string true "API key ID"
+// @Param X-Timestamp header string true "Unix seconds"
+// @Param X-Nonce header string true "Unique value per request"
+// @Param X-Signature header string true "Hex HMAC-SHA256 of the canonical request"
+// @Param redeem body models.PartnerPointsRequest true "Member, amount and partner reference"
+// @Success 200 {object} models.PartnerPointsResponse
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 401 {object} problem.Problem "Missing, invalid or replayed signature"
+// @Failure 403 {object} problem.Problem "API key lacks the scope"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 409 {object} problem.Problem "Reference already used or insufficient points"
+// @Failure 422 {object} problem.Problem "Account status does not allow the operation"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Router /partner/redeem [post]
+func (h *Handler) PartnerRedeem(c *fiber.Ctx) error {
+	return h.partnerPoints(c, models.ScopeRedeem)
+}
+
+// PartnerBalance godoc
+// @Summary Get member balance (partner)
+// @Description ร้านค้าพาร์ทเนอร์ดูยอดแต้มคงเหลือของสมาชิก ต้องใช้ API key ที่มี scope read-balance
+// @Tags Partner
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param X-Api-Key header string true "API key ID"
+// @Param X-Timestamp header string true "Unix seconds"
+// @Param X-Nonce header string true "Unique value per request"
+// @Param X-Signature header string true "Hex HMAC-SHA256 of the canonical request"
+// @Success 200 {object} models.PartnerBalanceResponse
+// @Failure 401 {object} problem.Problem "Missing, invalid or replayed signature"
+// @Failure 403 {object} problem.Problem "API key lacks the scope"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Router /partner/users/{id}/balance [get]
+func (h *Handler) PartnerBalance(c *fiber.Ctx) error {
+	balance, err := h.partners.Balance(c.UserContext(), paramID(c))
+	if err != nil {
+		return toProblem(err, "Failed to fetch balance")
+	}
+	return c.JSON(balance)
+}
+
+// Helper function to earn or redeem for the API key that signed the request
+func (h *Handler) partnerPoints(c *fiber.Ctx, scope models.APIKeyScope) error {
+	var req models.PartnerPointsRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	key, _ := auth.APIKeyFromContext(c.UserContext())
+	var result models.PartnerPointsResponse
+	var err error
+	if scope == models.ScopeEarn {
+		result, err = h.partners.Earn(c.UserContext(), key, req)
+	} else {
+		result, err = h.partners.Redeem(c.UserContext(), key, req)
+	}
+	if err != nil {
+		return toProblem(err, "Failed to apply points")
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTransferReviews godoc
+// @Summary Get transfer review queue
+// @Description ดูรายการโอนที่ถูกกักไว้ตรวจสอบโดยกฎป้องกันการทุจริต
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param status query string false "Review status (open/approved/rejected)" default(open)
+// @Success 200 {object} models.TransferReviewListResponse
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/transfer-reviews [get]
+func (h *Handler) GetTransferReviews(c *fiber.Ctx) error {
+	status := models.ReviewStatus(c.Query("status", string(models.ReviewOpen)))
+	if status != models.ReviewOpen && status != models.ReviewApproved && status != models.ReviewRejected {
+		return problem.Validation("Invalid query parameters",
+			problem.FieldError{Field: "status", Message: "must be one of open, approved, rejected"})
+	}
+
+	// Each review comes with the held transfer attached
+	reviews, err := h.transfers.Reviews(c.UserContext(), status)
+	if err != nil {
+		return problem.Internal("Failed to fetch transfer reviews", err)
+	}
+
+	return c.JSON(models.TransferReviewListResponse{
+		Data:  reviews,
+		Total: len(reviews),
+	})
+}
+
+// ApproveTransferReview godoc
+// @Summary Approve a held transfer
+// @Description อนุมัติรายการโอนที่ถูกกักไว้ ระบบจะโอนแต้มทันที
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param review body models.ReviewResolveRequest false "Reviewer note"
+// @Success 200 {object} models.TransferGetResponse
+// @Failure 404 {object} problem.Problem "Review not found"
+// @Failure 409 {object} problem.Problem "Review already resolved or insufficient points"
+// @Failure 422 {object} problem.Problem "Account status does not allow the transfer"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/transfer-reviews/{id}/approve [post]
+func (h *Handler) ApproveTransferReview(c *fiber.Ctx) error {
+	return h.resolveTransferReview(c, models.ReviewApproved)
+}
+
+// RejectTransferReview godoc
+// @Summary Reject a held transfer
+// @Description ปฏิเสธรายการโอนที่ถูกกักไว้ รายการโอนจะถูกบันทึกเป็น failed
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param review body models.ReviewResolveRequest false "Reviewer note"
+// @Success 200 {object} models.TransferGetResponse
+// @Failure 404 {object} problem.Problem "Review not found"
+// @Failure 409 {object} problem.Problem "Review already resolved"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /admin/transfer-reviews/{id}/reject [post]
+func (h *Handler) RejectTransferReview(c *fiber.Ctx) error {
+	return h.resolveTransferReview(c, models.ReviewRejected)
+}
+
+// Helper function to approve or reject an open review
+func (h *Handler) resolveTransferReview(c *fiber.Ctx, resolution models.ReviewStatus) error {
+	id := paramID(c)
+
+	var req models.ReviewResolveRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			return err
+		}
+	}
+
+	var transfer models.Transfer
+	var err error
+	if resolution == models.ReviewApproved {
+		transfer, err = h.transfers.ApproveReview(c.UserContext(), id, req.Note)
+	} else {
+		transfer, err = h.transfers.RejectReview(c.UserContext(), id, req.Note)
+	}
+	if err != nil {
+		return toProblem(err, "Failed to resolve review")
+	}
+
+	return c.JSON(models.TransferGetResponse{
+		Transfer: transfer,
+	})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchUsers godoc
+// @Summary Search users
+// @Description ค้นหาสมาชิกแบบ full-text จากชื่อ นามสกุล อีเมล เบอร์โทร (พิมพ์เฉพาะตัวเลขได้) และรหัสสมาชิก ค้นหาด้วยบางส่วนของคำได้ รวมถึงชื่อภาษาไทย ผลลัพธ์เรียงตามความเกี่ยวข้องและไฮไลต์ส่วนที่ตรงด้วย <mark> (บน PostgreSQL ผลลัพธ์ไม่ถูกจัดอันดับ)
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text, every word must match"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Success 200 {object} models.UserSearchResponse
+// @Failure 400 {object} problem.Problem "Missing or too long q"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /users/search [get]
+func (h *Handler) SearchUsers(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		return problem.Validation("Invalid query parameters",
+			problem.FieldError{Field: "q", Message: "is required"})
+	}
+	if utf8.RuneCountInString(q) > maxSearchLength {
+		return problem.Validation("Invalid query parameters",
+			problem.FieldError{Field: "q", Message: "must be at most " + strconv.Itoa(maxSearchLength) + " characters"})
+	}
+	terms := strings.Fields(q)
+
+	page, pageSize := h.pageParams(c)
+	hits, total, err := h.store.Users().Search(c.UserContext(), terms, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return problem.Internal("Failed to search users", err)
+	}
+
+	for i := range hits {
+		hits[i].Highlights = highlightUser(hits[i].User, terms)
+	}
+	return c.JSON(models.UserSearchResponse{
+		Data:     hits,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// Helper function to mark the search terms in the searchable fields of a user.
+// Fields without a match are left out.
+func highlightUser(user models.User, terms []string) map[string]string {
+	highlights := map[string]string{}
+	for _, field := range []struct {
+		name, value string
+		ignore      func(rune) bool
+	}{
+		{"first_name", user.FirstName, nil},
+		{"last_name", user.LastName, nil},
+		{"email", user.Email, nil},
+		{"phone_number", user.PhoneNumber, isPhoneSeparator},
+		{"membership_id", user.MembershipID, nil},
+	} {
+		if marked, ok := highlight(field.value, terms, field.ignore); ok {
+			highlights[field.name] = marked
+		}
+	}
+	return highlights
+}
+
+// Helper function to wrap every case-insensitive occurrence of the terms in
+// <mark></mark>, escaping the rest as HTML. Runes for which ignore returns
+// true are skipped when matching, so 0812345678 marks 081-234-5678.
+func highlight(value string, terms []string, ignore func(rune) bool) (string, bool) {
+	runes := []rune(value)
+
+	// Positions of the runes that take part in matching, lower-cased
+	var positions []int
+	var folded []rune
+	for i, r := range runes {
+		if ignore == nil || !ignore(r) {
+			positions = append(positions, i)
+			folded = append(folded, unicode.ToLower(r))
+		}
+	}
+
+	marked := make([]bool, len(runes))
+	found := false
+	for _, term := range terms {
+		var needle []rune
+		for _, r := range term {
+			if ignore == nil || !ignore(r) {
+				needle = append(needle, unicode.ToLower(r))
+			}
+		}
+		if len(needle) == 0 {
+			continue
+		}
+		for start := 0; start+len(needle) <= len(folded); start++ {
+			if string(folded[start:start+len(needle)]) != string(needle) {
+				continue
+			}
+			for i := positions[start]; i <= positions[start+len(needle)-1]; i++ {
+				marked[i] = true
+			}
+			found = true
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		text := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			text = "<mark>" + text + "</mark>"
+		}
+		b.WriteString(text)
+		i = j
+	}
+	return b.String(), true
+}
+
+// Helper function to skip the separators people type in phone numbers
+func isPhoneSeparator(r rune) bool {
+	return r == '-' || r == ' '
+}
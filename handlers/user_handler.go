@@ -1,109 +1,104 @@
 package handlers
 
 import (
-	"fmt"
-	"strings"
-	"temp-kbtg-backend/database"
+	"context"
+	"errors"
+	"log/slog"
+	"temp-kbtg-backend/audit"
+	"temp-kbtg-backend/auth"
 	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // GetAllUsers godoc
 // @Summary Get all users
-// @Description ดึงรายการผู้ใช้ทั้งหมด
+// @Description ค้นหาและดึงรายการผู้ใช้แบบแบ่งหน้า (ไม่แสดงบัญชีที่ปิดแล้ว เว้นแต่ระบุ status=closed) ค้นหาข้อความได้จากชื่อ นามสกุล อีเมล เบอร์โทร และรหัสสมาชิก (รองรับภาษาไทย) แบ่งหน้าได้ทั้งแบบ page และ cursor
 // @Tags Users
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]interface{} "success, data, total"
-// @Failure 500 {object} map[string]interface{} "error response"
+// @Param q query string false "Free-text search, every word must match"
+// @Param status query string false "Account status (active/frozen/suspended/closed)"
+// @Param membershipLevel query string false "Membership level (Bronze/Silver/Gold)"
+// @Param minPoints query int false "Minimum points"
+// @Param maxPoints query int false "Maximum points"
+// @Param joinedFrom query string false "Joined on or after (YYYY-MM-DD)"
+// @Param joinedTo query string false "Joined on or before (YYYY-MM-DD)"
+// @Param sort query string false "Sort field (id/membership_id/first_name/last_name/points/joined_date), prefix - for descending" default(-id)
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Page size" default(20)
+// @Param cursor query string false "nextCursor of the previous page, instead of page"
+// @Success 200 {object} models.UserListResponse
+// @Failure 400 {object} problem.Problem "Invalid query parameters"
+// @Failure 500 {object} problem.Problem "error response"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
 // @Router /users [get]
-func GetAllUsers(c *fiber.Ctx) error {
-	rows, err := database.DB.Query(`
-		SELECT id, membership_id, first_name, last_name, phone_number, email, 
-		       membership_level, points, joined_date, created_at, updated_at 
-		FROM users
-		ORDER BY id DESC
-	`)
+func (h *Handler) GetAllUsers(c *fiber.Ctx) error {
+	filter, page, err := h.parseUserQuery(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to fetch users",
-			"error":   err.Error(),
-		})
+		return err
 	}
-	defer rows.Close()
-
-	users := []models.User{}
-	for rows.Next() {
-		var user models.User
-		err := rows.Scan(
-			&user.ID,
-			&user.MembershipID,
-			&user.FirstName,
-			&user.LastName,
-			&user.PhoneNumber,
-			&user.Email,
-			&user.MembershipLevel,
-			&user.Points,
-			&user.JoinedDate,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"message": "Failed to scan user",
-				"error":   err.Error(),
-			})
-		}
-		users = append(users, user)
+
+	// Read one extra row to know whether another page follows
+	limit := page.Limit
+	page.Limit++
+	users, total, err := h.store.Users().List(c.UserContext(), filter, page)
+	if err != nil {
+		return problem.Internal("Failed to fetch users", err)
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    users,
-		"total":   len(users),
-	})
+	response := models.UserListResponse{Data: users, PageSize: limit, Total: total}
+	if page.After == nil {
+		response.Page = page.Offset/limit + 1
+	}
+	if len(users) > limit {
+		response.Data = users[:limit]
+		response.NextCursor = encodeUserCursor(page, users[limit-1])
+	}
+	return c.JSON(response)
 }
 
 // GetUserByID godoc
 // @Summary Get user by ID
-// @Description ดึงข้อมูลผู้ใช้รายบุคคล
+// @Description ดึงข้อมูลผู้ใช้รายบุคคล พร้อม header ETag สำหรับใช้เป็น If-Match ตอนแก้ไข ส่ง If-None-Match เพื่อรับ 304 เมื่อข้อมูลไม่เปลี่ยน
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
+// @Param If-None-Match header string false "ETag from an earlier response"
 // @Success 200 {object} map[string]interface{} "success, data"
-// @Failure 404 {object} map[string]interface{} "User not found"
+// @Success 304 "User has not changed"
+// @Header 200 {string} ETag "User version"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
 // @Router /users/{id} [get]
-func GetUserByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-
-	var user models.User
-	err := database.DB.QueryRow(`
-		SELECT id, membership_id, first_name, last_name, phone_number, email, 
-		       membership_level, points, joined_date, created_at, updated_at 
-		FROM users WHERE id = ?
-	`, id).Scan(
-		&user.ID,
-		&user.MembershipID,
-		&user.FirstName,
-		&user.LastName,
-		&user.PhoneNumber,
-		&user.Email,
-		&user.MembershipLevel,
-		&user.Points,
-		&user.JoinedDate,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+func (h *Handler) GetUserByID(c *fiber.Ctx) error {
+	id := paramID(c)
+	if err := authorizeUser(c, id); err != nil {
+		return err
+	}
 
+	user, err := h.store.Users().GetByID(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return errUserNotFound()
+	}
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "User not found",
-		})
+		return problem.Internal("Failed to fetch user", err)
+	}
+
+	etag := userETag(user)
+	c.Set(fiber.HeaderETag, etag)
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" && etagMatches(header, etag, true) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(fiber.Map{
@@ -120,82 +115,60 @@ func GetUserByID(c *fiber.Ctx) error {
 // @Produce json
 // @Param user body models.CreateUserRequest true "User data"
 // @Success 201 {object} map[string]interface{} "success, message, data"
-// @Failure 400 {object} map[string]interface{} "Validation error"
-// @Failure 409 {object} map[string]interface{} "Email already exists"
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 409 {object} problem.Problem "Email already exists"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
 // @Router /users [post]
-func CreateUser(c *fiber.Ctx) error {
+func (h *Handler) CreateUser(c *fiber.Ctx) error {
 	var req models.CreateUserRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request body",
-			"error":   err.Error(),
-		})
-	}
-
-	// Validate required fields
-	if req.FirstName == "" || req.LastName == "" || req.PhoneNumber == "" || req.Email == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "First name, last name, phone number, and email are required",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
 	// Set default membership level if not provided
 	if req.MembershipLevel == "" {
-		req.MembershipLevel = "Bronze"
+		req.MembershipLevel = models.LevelBronze
 	}
 
-	// Generate membership ID
-	membershipID, err := generateMembershipID()
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to generate membership ID",
-			"error":   err.Error(),
-		})
-	}
+	ctx := c.UserContext()
+	var user models.User
+	err := h.store.WithinTx(ctx, func(tx repository.Store) error {
+		// Generate membership ID
+		membershipID, err := tx.Users().NextMembershipID(ctx)
+		if err != nil {
+			return problem.Internal("Failed to generate membership ID", err)
+		}
 
-	// Insert user
-	result, err := database.DB.Exec(`
-		INSERT INTO users (membership_id, first_name, last_name, phone_number, email, membership_level, points, joined_date)
-		VALUES (?, ?, ?, ?, ?, ?, 0, CURRENT_TIMESTAMP)
-	`, membershipID, req.FirstName, req.LastName, req.PhoneNumber, req.Email, req.MembershipLevel)
+		// Insert user
+		user = models.User{
+			MembershipID:    membershipID,
+			FirstName:       req.FirstName,
+			LastName:        req.LastName,
+			PhoneNumber:     req.PhoneNumber,
+			Email:           req.Email,
+			MembershipLevel: req.MembershipLevel,
+		}
+		err = tx.Users().Create(ctx, &user)
+		if err == repository.ErrConflict {
+			return problem.New(fiber.StatusConflict, "DUPLICATE_USER", "Email or membership ID already exists")
+		}
+		if err != nil {
+			return err
+		}
 
+		return auditUser(ctx, tx, models.AuditUserCreate, nil, user.ID)
+	})
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"success": false,
-				"message": "Email or membership ID already exists",
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to create user",
-			"error":   err.Error(),
-		})
+		return toProblem(err, "Failed to create user")
 	}
 
-	userID, _ := result.LastInsertId()
-
-	// Fetch the created user
-	var user models.User
-	database.DB.QueryRow(`
-		SELECT id, membership_id, first_name, last_name, phone_number, email, 
-		       membership_level, points, joined_date, created_at, updated_at 
-		FROM users WHERE id = ?
-	`, userID).Scan(
-		&user.ID,
-		&user.MembershipID,
-		&user.FirstName,
-		&user.LastName,
-		&user.PhoneNumber,
-		&user.Email,
-		&user.MembershipLevel,
-		&user.Points,
-		&user.JoinedDate,
-		&user.CreatedAt,
-		&user.UpdatedAt,
+	slog.InfoContext(ctx, "user created",
+		"user_id", user.ID,
+		"membership_id", user.MembershipID,
+		"membership_level", user.MembershipLevel,
 	)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -207,107 +180,73 @@ func CreateUser(c *fiber.Ctx) error {
 
 // UpdateUser godoc
 // @Summary Update user
-// @Description แก้ไขข้อมูลผู้ใช้
+// @Description แก้ไขข้อมูลผู้ใช้ ต้องส่ง If-Match เป็น ETag จาก GET /users/{id} หากผู้ใช้ถูกแก้ไขไปแล้วจะได้ 412 พร้อม ETag ปัจจุบัน การเปลี่ยน points บันทึกส่วนต่างเป็น ledger ประเภท adjust
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
+// @Param If-Match header string true "ETag of the user being updated"
 // @Param user body models.UpdateUserRequest true "User update data"
 // @Success 200 {object} map[string]interface{} "success, message, data"
-// @Failure 400 {object} map[string]interface{} "Validation error"
-// @Failure 404 {object} map[string]interface{} "User not found"
+// @Header 200 {string} ETag "New user version"
+// @Failure 400 {object} problem.Problem "Validation error"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 409 {object} problem.Problem "User account is closed or email already exists"
+// @Failure 412 {object} problem.Problem "User was modified since it was read"
+// @Failure 428 {object} problem.Problem "Missing If-Match header"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
 // @Router /users/{id} [put]
-func UpdateUser(c *fiber.Ctx) error {
-	id := c.Params("id")
+func (h *Handler) UpdateUser(c *fiber.Ctx) error {
+	id := paramID(c)
 
-	var req models.UpdateUserRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request body",
-			"error":   err.Error(),
-		})
+	if err := authorizeUser(c, id); err != nil {
+		return err
 	}
 
-	// Check if user exists
-	var exists int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", id).Scan(&exists)
-	if err != nil || exists == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "User not found",
-		})
+	ifMatch, err := requireIfMatch(c)
+	if err != nil {
+		return err
 	}
 
-	// Build update query dynamically
-	updates := []string{}
-	args := []interface{}{}
-
-	if req.FirstName != "" {
-		updates = append(updates, "first_name = ?")
-		args = append(args, req.FirstName)
-	}
-	if req.LastName != "" {
-		updates = append(updates, "last_name = ?")
-		args = append(args, req.LastName)
-	}
-	if req.PhoneNumber != "" {
-		updates = append(updates, "phone_number = ?")
-		args = append(args, req.PhoneNumber)
-	}
-	if req.Email != "" {
-		updates = append(updates, "email = ?")
-		args = append(args, req.Email)
-	}
-	if req.MembershipLevel != "" {
-		updates = append(updates, "membership_level = ?")
-		args = append(args, req.MembershipLevel)
-	}
-	if req.Points != nil {
-		updates = append(updates, "points = ?")
-		args = append(args, *req.Points)
+	var req models.UpdateUserRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if len(updates) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "No fields to update",
-		})
+	ctx := c.UserContext()
+	if err := authorizeUserFields(ctx, req); err != nil {
+		return err
 	}
 
-	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
-	args = append(args, id)
+	err = h.store.WithinTx(ctx, func(tx repository.Store) error {
+		existing, err := lockEditableUser(c, tx, id, ifMatch)
+		if err != nil {
+			return err
+		}
+
+		if req.FirstName == "" && req.LastName == "" && req.PhoneNumber == "" && req.Email == "" &&
+			req.MembershipLevel == "" && req.Points == nil {
+			return problem.Validation("No fields to update")
+		}
 
-	query := fmt.Sprintf("UPDATE users SET %s WHERE id = ?", strings.Join(updates, ", "))
-	_, err = database.DB.Exec(query, args...)
+		if err := updateUser(ctx, tx, existing, req); err != nil {
+			return err
+		}
 
+		return auditUser(ctx, tx, models.AuditUserUpdate, &existing, id)
+	})
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to update user",
-			"error":   err.Error(),
-		})
+		return toProblem(err, "Failed to update user")
 	}
 
+	slog.InfoContext(ctx, "user updated", "user_id", id, "fields", updatedFields(req))
+
 	// Fetch updated user
-	var user models.User
-	database.DB.QueryRow(`
-		SELECT id, membership_id, first_name, last_name, phone_number, email, 
-		       membership_level, points, joined_date, created_at, updated_at 
-		FROM users WHERE id = ?
-	`, id).Scan(
-		&user.ID,
-		&user.MembershipID,
-		&user.FirstName,
-		&user.LastName,
-		&user.PhoneNumber,
-		&user.Email,
-		&user.MembershipLevel,
-		&user.Points,
-		&user.JoinedDate,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, _ := h.store.Users().GetByID(ctx, id)
+	c.Set(fiber.HeaderETag, userETag(user))
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -317,53 +256,195 @@ func UpdateUser(c *fiber.Ctx) error {
 }
 
 // DeleteUser godoc
-// @Summary Delete user
-// @Description ลบผู้ใช้
+// @Summary Close user account
+// @Description ปิดบัญชีผู้ใช้ (soft delete) ข้อมูลและประวัติการโอนยังคงอยู่ แต้มคงเหลือจะถูกโอนเข้าบัญชีระบบผ่าน ledger
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
-// @Success 200 {object} map[string]interface{} "success, message"
-// @Failure 404 {object} map[string]interface{} "User not found"
+// @Param close body models.UserCloseRequest false "Reason"
+// @Success 200 {object} map[string]interface{} "success, message, data, forfeited_points"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 409 {object} problem.Problem "User account is already closed"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
 // @Router /users/{id} [delete]
-func DeleteUser(c *fiber.Ctx) error {
-	id := c.Params("id")
+func (h *Handler) DeleteUser(c *fiber.Ctx) error {
+	id := paramID(c)
 
-	// Check if user exists
-	var exists int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", id).Scan(&exists)
-	if err != nil || exists == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "User not found",
-		})
+	var req models.UserCloseRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			return err
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "Account closed"
+	}
+	if err := authorizeClose(c); err != nil {
+		return err
 	}
 
-	// Delete user
-	_, err = database.DB.Exec("DELETE FROM users WHERE id = ?", id)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to delete user",
-			"error":   err.Error(),
+	ctx := c.UserContext()
+	actor, _ := audit.Actor(ctx)
+	var forfeited int
+	err := h.store.WithinTx(ctx, func(tx repository.Store) error {
+		// Check if user exists
+		user, err := getMember(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if user.Status == models.UserClosed {
+			return problem.New(fiber.StatusConflict, "ALREADY_CLOSED", "User account is already closed")
+		}
+
+		// Close the account instead of deleting it, so transfers and ledger keep their references
+		now := time.Now().UTC()
+		forfeited, err = closeUser(ctx, tx, user, now)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Users().AddStatusChange(ctx, &models.UserStatusChange{
+			UserID:     user.ID,
+			FromStatus: user.Status,
+			ToStatus:   models.UserClosed,
+			Reason:     req.Reason,
+			Actor:      actor,
+			CreatedAt:  now,
 		})
+		if err != nil {
+			return err
+		}
+
+		return auditUser(ctx, tx, models.AuditUserClose, &user, user.ID)
+	})
+	if err != nil {
+		return toProblem(err, "Failed to close user account")
 	}
 
+	slog.InfoContext(ctx, "user closed", "user_id", id, "forfeited_points", forfeited, "actor", actor)
+
+	// Fetch closed user
+	user, _ := h.store.Users().GetByID(ctx, id)
+
 	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "User deleted successfully",
+		"success":          true,
+		"message":          "User account closed successfully",
+		"data":             user,
+		"forfeited_points": forfeited,
 	})
 }
 
-// Helper function to generate membership ID
-func generateMembershipID() (string, error) {
-	var lastID int
-	err := database.DB.QueryRow("SELECT COALESCE(MAX(id), 0) FROM users").Scan(&lastID)
+// Helper function to check the fields of an update only some roles may change.
+// Members edit their profile only, points are changed by admins.
+func authorizeUserFields(ctx context.Context, req models.UpdateUserRequest) error {
+	principal, _ := auth.FromContext(ctx)
+	if req.Points != nil && principal.Role != auth.RoleAdmin {
+		return auth.Forbidden("Only admins may change points")
+	}
+	if req.MembershipLevel != "" && !principal.IsStaff() {
+		return auth.Forbidden("Members may not change their membership level")
+	}
+	return nil
+}
+
+// Helper function to load a user about to be edited in tx. The row is locked so
+// its version cannot change between the If-Match check and the update, closed
+// accounts are refused and the system account is reported as not found.
+func lockEditableUser(c *fiber.Ctx, tx repository.Store, id int, ifMatch string) (models.User, error) {
+	// Check if user exists
+	user, err := tx.Users().GetByIDForUpdate(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) || user.MembershipID == models.SystemMembershipID {
+		return models.User{}, errUserNotFound()
+	}
+	if err != nil {
+		return models.User{}, problem.Internal("Failed to fetch user", err)
+	}
+
+	if err := checkIfMatch(c, ifMatch, user); err != nil {
+		return models.User{}, err
+	}
+
+	// Closed accounts are kept for history only
+	if user.Status == models.UserClosed {
+		return models.User{}, problem.New(fiber.StatusConflict, "ACCOUNT_CLOSED", "User account is closed")
+	}
+	return user, nil
+}
+
+// Helper function to write an update of user in tx. The profile fields go
+// through Update, a new balance is applied as the difference with an adjust
+// entry in the ledger, so no balance changes without one.
+func updateUser(ctx context.Context, tx repository.Store, user models.User, req models.UpdateUserRequest) error {
+	if req.FirstName != "" || req.LastName != "" || req.PhoneNumber != "" || req.Email != "" || req.MembershipLevel != "" {
+		err := tx.Users().Update(ctx, user.ID, req)
+		if err == repository.ErrConflict {
+			return problem.New(fiber.StatusConflict, "DUPLICATE_USER", "Email already exists")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if req.Points == nil || *req.Points == user.Points {
+		return nil
+	}
+	change := *req.Points - user.Points
+	balance, err := tx.Users().AdjustPoints(ctx, user.ID, change)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	actor, _ := audit.Actor(ctx)
+	reference := "admin_adjustment:" + actor
+	return tx.Ledger().Append(ctx, models.PointLedger{
+		UserID:       user.ID,
+		Change:       change,
+		BalanceAfter: balance,
+		EventType:    models.EventAdjust,
+		Reference:    &reference,
+		CreatedAt:    time.Now().UTC(),
+	})
+}
+
+// Helper function to load a member account, the system account is reported as not found
+func getMember(ctx context.Context, tx repository.Store, id int) (models.User, error) {
+	user, err := tx.Users().GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || user.MembershipID == models.SystemMembershipID {
+		return models.User{}, errUserNotFound()
 	}
+	return user, err
+}
+
+// Helper function to report a missing or system user
+func errUserNotFound() *problem.Problem {
+	return problem.New(fiber.StatusNotFound, problem.CodeNotFound, "User not found")
+}
 
-	nextID := lastID + 1
-	membershipID := fmt.Sprintf("LBK%06d", nextID)
-	return membershipID, nil
+// Helper function to list the names of the fields set in an update, never their values
+func updatedFields(req models.UpdateUserRequest) []string {
+	var fields []string
+	if req.FirstName != "" {
+		fields = append(fields, "first_name")
+	}
+	if req.LastName != "" {
+		fields = append(fields, "last_name")
+	}
+	if req.PhoneNumber != "" {
+		fields = append(fields, "phone_number")
+	}
+	if req.Email != "" {
+		fields = append(fields, "email")
+	}
+	if req.MembershipLevel != "" {
+		fields = append(fields, "membership_level")
+	}
+	if req.Points != nil {
+		fields = append(fields, "points")
+	}
+	return fields
 }
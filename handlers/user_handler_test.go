@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"context"
+	"strconv"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/patch"
+	"temp-kbtg-backend/problem"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper function to PUT an update of a user
+func (s *testServer) updateUser(t *testing.T, id int, token, ifMatch, body string) testResponse {
+	t.Helper()
+	return s.do(t, fiber.MethodPut, "/users/"+strconv.Itoa(id), token,
+		map[string]string{fiber.HeaderIfMatch: ifMatch}, body)
+}
+
+// Helper function to fail unless the ledger of a user holds exactly the given changes
+func expectLedger(t *testing.T, s *testServer, id int, changes ...int) []models.PointLedger {
+	t.Helper()
+	entries, err := s.store.Ledger().ListByUser(context.Background(), id)
+	if err != nil {
+		t.Fatalf("ledger: %v", err)
+	}
+	if len(entries) != len(changes) {
+		t.Fatalf("%d ledger entries, want %d: %+v", len(entries), len(changes), entries)
+	}
+	for i, entry := range entries {
+		if entry.Change != changes[i] {
+			t.Errorf("entry %d changes %d, want %d", i, entry.Change, changes[i])
+		}
+	}
+	return entries
+}
+
+func TestUpdateUserRecordsPointsInLedger(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createMember(t, "somchai@example.com", 100)
+	admin := s.token(t, "admin-1", auth.RoleAdmin)
+
+	res := s.updateUser(t, user.ID, admin, `"1"`, `{"first_name": "สมศักดิ์", "points": 250}`)
+	if res.status != fiber.StatusOK {
+		t.Fatalf("got %d: %v", res.status, res.body)
+	}
+
+	got, _ := s.store.Users().GetByID(context.Background(), user.ID)
+	if got.Points != 250 || got.FirstName != "สมศักดิ์" {
+		t.Errorf("user is %+v", got)
+	}
+	entries := expectLedger(t, s, user.ID, 150)
+	if e := entries[0]; e.EventType != models.EventAdjust || e.BalanceAfter != 250 || e.Reference == nil || *e.Reference != "admin_adjustment:admin-1" {
+		t.Errorf("ledger entry is %+v", e)
+	}
+
+	// Lowering the balance is a debit, an unchanged balance writes nothing
+	res = s.updateUser(t, user.ID, admin, res.header.Get(fiber.HeaderETag), `{"points": 40}`)
+	if res.status != fiber.StatusOK {
+		t.Fatalf("got %d: %v", res.status, res.body)
+	}
+	res = s.updateUser(t, user.ID, admin, res.header.Get(fiber.HeaderETag), `{"points": 40}`)
+	if res.status != fiber.StatusOK {
+		t.Fatalf("got %d: %v", res.status, res.body)
+	}
+	expectLedger(t, s, user.ID, 150, -210)
+}
+
+func TestPatchUserRecordsPointsInLedger(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createMember(t, "somchai@example.com", 100)
+	admin := s.token(t, "admin-1", auth.RoleAdmin)
+
+	res := s.patchUser(t, user.ID, admin, patch.JSONPatchType, `"1"`, `[{"op": "replace", "path": "/points", "value": 30}]`)
+	if res.status != fiber.StatusOK {
+		t.Fatalf("got %d: %v", res.status, res.body)
+	}
+	if got, _ := s.store.Users().GetByID(context.Background(), user.ID); got.Points != 30 {
+		t.Errorf("balance is %d, want 30", got.Points)
+	}
+	expectLedger(t, s, user.ID, -70)
+}
+
+func TestUpdateUserPointsNeedAdmin(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createMember(t, "somchai@example.com", 100)
+
+	for _, token := range []string{
+		s.token(t, strconv.Itoa(user.ID), auth.RoleMember),
+		s.token(t, "support-1", auth.RoleSupport),
+	} {
+		res := s.updateUser(t, user.ID, token, `"1"`, `{"points": 1000}`)
+		expectProblem(t, res, fiber.StatusForbidden, auth.CodeForbidden)
+	}
+	expectLedger(t, s, user.ID)
+}
+
+func TestUpdateUserHidesSystemAccount(t *testing.T) {
+	s := newTestServer(t)
+	system, err := s.store.Users().GetByMembershipID(context.Background(), models.SystemMembershipID)
+	if err != nil {
+		t.Fatalf("system account: %v", err)
+	}
+	admin := s.token(t, "admin-1", auth.RoleAdmin)
+
+	res := s.updateUser(t, system.ID, admin, "*", `{"points": 0}`)
+	expectProblem(t, res, fiber.StatusNotFound, problem.CodeNotFound)
+	res = s.patchUser(t, system.ID, admin, patch.MergePatchType, "*", `{"first_name": "Renamed"}`)
+	expectProblem(t, res, fiber.StatusNotFound, problem.CodeNotFound)
+
+	if got, _ := s.store.Users().GetByID(context.Background(), system.ID); got.Version != system.Version {
+		t.Errorf("system account changed to version %d", got.Version)
+	}
+}
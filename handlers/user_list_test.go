@@ -0,0 +1,129 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/problem"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper function to list users as support staff with the given query parameters
+func (s *testServer) listUsers(t *testing.T, query url.Values) testResponse {
+	t.Helper()
+	return s.do(t, fiber.MethodGet, "/users?"+query.Encode(), s.token(t, "support-1", auth.RoleSupport), nil, "")
+}
+
+// Helper function to read the user IDs and next cursor of a list response
+func listedIDs(t *testing.T, res testResponse) ([]int, string) {
+	t.Helper()
+	if res.status != fiber.StatusOK {
+		t.Fatalf("got %d: %v", res.status, res.body)
+	}
+	data, _ := res.body["data"].([]interface{})
+	ids := make([]int, 0, len(data))
+	for _, item := range data {
+		user, _ := item.(map[string]interface{})
+		id, _ := user["id"].(float64)
+		ids = append(ids, int(id))
+	}
+	cursor, _ := res.body["nextCursor"].(string)
+	return ids, cursor
+}
+
+// Helper function to create members with repeating points, so sorts have ties to break by ID
+func (s *testServer) createMembers(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		s.createMember(t, fmt.Sprintf("member%d@example.com", i), (i%3)*100)
+	}
+}
+
+func TestListUsersCursorWalksEverySort(t *testing.T) {
+	s := newTestServer(t)
+	s.createMembers(t, 7)
+
+	for _, field := range []string{"id", "membership_id", "first_name", "last_name", "points", "joined_date"} {
+		for _, sort := range []string{field, "-" + field} {
+			t.Run(sort, func(t *testing.T) {
+				want, _ := listedIDs(t, s.listUsers(t, url.Values{"sort": {sort}, "pageSize": {"100"}}))
+
+				// Following nextCursor three at a time visits the same users in the same order
+				var got []int
+				query := url.Values{"sort": {sort}, "pageSize": {"3"}}
+				for pages := 0; ; pages++ {
+					if pages > len(want) {
+						t.Fatalf("cursor does not advance after %v", got)
+					}
+					ids, cursor := listedIDs(t, s.listUsers(t, query))
+					got = append(got, ids...)
+					if cursor == "" {
+						break
+					}
+					query.Set("cursor", cursor)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("cursor pages give %v, want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestListUsersCursorKeepsItsSort(t *testing.T) {
+	s := newTestServer(t)
+	s.createMembers(t, 5)
+
+	first, cursor := listedIDs(t, s.listUsers(t, url.Values{"sort": {"-points"}, "pageSize": {"2"}}))
+	if cursor == "" {
+		t.Fatal("first page has no nextCursor")
+	}
+	want, _ := listedIDs(t, s.listUsers(t, url.Values{"sort": {"-points"}, "pageSize": {"4"}}))
+
+	// The sort parameter may be left out, the cursor carries it
+	second, _ := listedIDs(t, s.listUsers(t, url.Values{"cursor": {cursor}, "pageSize": {"2"}}))
+	if got := append(first, second...); !reflect.DeepEqual(got, want) {
+		t.Errorf("pages give %v, want %v", got, want)
+	}
+}
+
+func TestListUsersRejectsBadCursors(t *testing.T) {
+	s := newTestServer(t)
+	s.createMembers(t, 5)
+	_, cursor := listedIDs(t, s.listUsers(t, url.Values{"sort": {"points"}, "pageSize": {"2"}}))
+	if cursor == "" {
+		t.Fatal("first page has no nextCursor")
+	}
+	tampered := []byte(cursor)
+	tampered[len(tampered)/2] ^= 0x20
+
+	tests := []struct {
+		name  string
+		query url.Values
+	}{
+		{"tampered cursor", url.Values{"cursor": {string(tampered)}}},
+		{"truncated cursor", url.Values{"cursor": {cursor[:len(cursor)-3]}}},
+		{"garbage cursor", url.Values{"cursor": {"bm90IGEgY3Vyc29y"}}},
+		{"different direction", url.Values{"cursor": {cursor}, "sort": {"-points"}}},
+		{"different field", url.Values{"cursor": {cursor}, "sort": {"last_name"}}},
+		{"cursor with page", url.Values{"cursor": {cursor}, "sort": {"points"}, "page": {"2"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := s.listUsers(t, tt.query)
+			expectProblem(t, res, fiber.StatusBadRequest, problem.CodeValidation)
+			if !hasFieldError(res, "cursor") {
+				t.Errorf("no error for cursor: %v", res.body["errors"])
+			}
+		})
+	}
+
+	// The same cursor with its own sort is accepted
+	res := s.listUsers(t, url.Values{"cursor": {cursor}, "sort": {"points"}, "pageSize": {"2"}})
+	if res.status != fiber.StatusOK {
+		t.Errorf("matching sort got %d: %v", res.status, res.body)
+	}
+}
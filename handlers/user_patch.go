@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"reflect"
+	"sort"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/patch"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// acceptPatch lists the patch formats of PATCH /users/:id for the Accept-Patch header
+const acceptPatch = patch.MergePatchType + ", " + patch.JSONPatchType
+
+// editableUserFields are the members of a user document a PATCH may change,
+// the JSON names of models.UserPatchDocument
+var editableUserFields = map[string]bool{
+	"first_name":       true,
+	"last_name":        true,
+	"phone_number":     true,
+	"email":            true,
+	"membership_level": true,
+	"points":           true,
+}
+
+// PatchUser godoc
+// @Summary Patch user
+// @Description แก้ไขข้อมูลผู้ใช้บางส่วนด้วย JSON Merge Patch (RFC 7396, Content-Type application/merge-patch+json) หรือ JSON Patch (RFC 6902, Content-Type application/json-patch+json) ต้องส่ง If-Match เหมือน PUT ข้อมูลทั้งหมดหลัง patch ต้องผ่านการตรวจสอบ ทุกฟิลด์จำเป็นต้องมีค่า จึงลบค่าด้วย null หรือ remove ไม่ได้ และได้ข้อมูลผู้ใช้ทั้งหมดกลับมา
+// @Tags Users
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param If-Match header string true "ETag of the user being updated"
+// @Param patch body object true "Merge patch object or array of JSON Patch operations"
+// @Success 200 {object} map[string]interface{} "success, message, data"
+// @Header 200 {string} ETag "New user version"
+// @Failure 400 {object} problem.Problem "Malformed patch or invalid patched user"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 409 {object} problem.Problem "User account is closed, email already exists or a test operation failed"
+// @Failure 412 {object} problem.Problem "User was modified since it was read"
+// @Failure 415 {object} problem.Problem "Unsupported patch format"
+// @Failure 422 {object} problem.Problem "Patch refers to a missing path"
+// @Failure 428 {object} problem.Problem "Missing If-Match header"
+// @Failure 401 {object} problem.Problem "Missing or invalid token"
+// @Failure 403 {object} problem.Problem "Role or account not allowed"
+// @Failure 429 {object} problem.Problem "Rate limit exceeded"
+// @Security BearerAuth
+// @Router /users/{id} [patch]
+func (h *Handler) PatchUser(c *fiber.Ctx) error {
+	id := paramID(c)
+
+	if err := authorizeUser(c, id); err != nil {
+		return err
+	}
+
+	ifMatch, err := requireIfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	p, err := parsePatch(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.UserContext()
+	var req models.UpdateUserRequest
+	err = h.store.WithinTx(ctx, func(tx repository.Store) error {
+		existing, err := lockEditableUser(c, tx, id, ifMatch)
+		if err != nil {
+			return err
+		}
+
+		req, err = applyUserPatch(existing, p)
+		if err != nil {
+			return err
+		}
+		if err := authorizeUserFields(ctx, req); err != nil {
+			return err
+		}
+
+		// Nothing changed, the user keeps its version
+		if len(updatedFields(req)) == 0 {
+			return nil
+		}
+
+		if err := updateUser(ctx, tx, existing, req); err != nil {
+			return err
+		}
+
+		return auditUser(ctx, tx, models.AuditUserUpdate, &existing, id)
+	})
+	if err != nil {
+		return toProblem(err, "Failed to update user")
+	}
+
+	slog.InfoContext(ctx, "user patched", "user_id", id, "fields", updatedFields(req))
+
+	// Fetch updated user
+	user, _ := h.store.Users().GetByID(ctx, id)
+	c.Set(fiber.HeaderETag, userETag(user))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User updated successfully",
+		"data":    user,
+	})
+}
+
+// Helper function to parse the body of a PATCH request in the format named by its Content-Type
+func parsePatch(c *fiber.Ctx) (patch.Patch, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+
+	var p patch.Patch
+	var err error
+	switch mediaType {
+	case patch.MergePatchType:
+		p, err = patch.ParseMerge(c.Body())
+	case patch.JSONPatchType:
+		p, err = patch.ParseJSON(c.Body())
+	default:
+		c.Set("Accept-Patch", acceptPatch)
+		return nil, problem.New(fiber.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE",
+			"Content-Type must be one of "+acceptPatch)
+	}
+	if err != nil {
+		return nil, problem.New(fiber.StatusBadRequest, "INVALID_PATCH", err.Error())
+	}
+	return p, nil
+}
+
+// Helper function to apply a patch to the JSON document of a user and validate
+// the result. It returns an update holding only the fields that changed.
+func applyUserPatch(user models.User, p patch.Patch) (models.UpdateUserRequest, error) {
+	// Patch the user as clients read it, keeping a copy to compare with
+	encoded, err := json.Marshal(user)
+	if err != nil {
+		return models.UpdateUserRequest{}, err
+	}
+	var before, doc map[string]interface{}
+	if err := json.Unmarshal(encoded, &before); err != nil {
+		return models.UpdateUserRequest{}, err
+	}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return models.UpdateUserRequest{}, err
+	}
+
+	patched, err := p.Apply(doc)
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		return models.UpdateUserRequest{}, problem.New(fiber.StatusConflict, "PATCH_TEST_FAILED", err.Error())
+	case errors.Is(err, patch.ErrPathNotFound):
+		return models.UpdateUserRequest{}, problem.New(fiber.StatusUnprocessableEntity, "PATCH_NOT_APPLICABLE", err.Error())
+	case err != nil:
+		return models.UpdateUserRequest{}, problem.New(fiber.StatusBadRequest, "INVALID_PATCH", err.Error())
+	}
+	after, ok := patched.(map[string]interface{})
+	if !ok {
+		return models.UpdateUserRequest{}, problem.Validation("Patched user must be a JSON object")
+	}
+
+	if err := checkReadOnlyFields(before, after); err != nil {
+		return models.UpdateUserRequest{}, err
+	}
+
+	// Validate the whole resulting document, not only the patched fields
+	var document models.UserPatchDocument
+	encoded, err = json.Marshal(after)
+	if err != nil {
+		return models.UpdateUserRequest{}, err
+	}
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return models.UpdateUserRequest{}, problem.Validation("Request validation failed",
+				problem.FieldError{Field: typeErr.Field, Message: typeMessage(typeErr.Type)})
+		}
+		return models.UpdateUserRequest{}, invalidBody(err)
+	}
+	if err := validateRequest(document); err != nil {
+		return models.UpdateUserRequest{}, err
+	}
+
+	// Keep the changed fields only
+	var req models.UpdateUserRequest
+	if document.FirstName != user.FirstName {
+		req.FirstName = document.FirstName
+	}
+	if document.LastName != user.LastName {
+		req.LastName = document.LastName
+	}
+	if document.PhoneNumber != user.PhoneNumber {
+		req.PhoneNumber = document.PhoneNumber
+	}
+	if document.Email != user.Email {
+		req.Email = document.Email
+	}
+	if document.MembershipLevel != user.MembershipLevel {
+		req.MembershipLevel = document.MembershipLevel
+	}
+	if *document.Points != user.Points {
+		req.Points = document.Points
+	}
+	return req, nil
+}
+
+// Helper function to reject a patch that changes a field outside
+// editableUserFields or adds a member users do not have
+func checkReadOnlyFields(before, after map[string]interface{}) error {
+	names := map[string]bool{}
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	var fields []problem.FieldError
+	for name := range names {
+		if editableUserFields[name] || reflect.DeepEqual(before[name], after[name]) {
+			continue
+		}
+		message := "is read-only"
+		if _, known := before[name]; !known && !userFieldNames[name] {
+			message = "is not a user field"
+		}
+		fields = append(fields, problem.FieldError{Field: name, Message: message})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return problem.Validation("Request validation failed", fields...)
+}
+
+// userFieldNames are the JSON names of models.User, including members left
+// out of a document when empty
+var userFieldNames = jsonFieldNames(reflect.TypeOf(models.User{}))
+
+// Helper function to list the JSON member names of a struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// Helper function to describe the JSON type a field expects
+func typeMessage(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "must be a string"
+	case reflect.Int, reflect.Int64:
+		return "must be an integer"
+	}
+	return "has the wrong type"
+}
@@ -0,0 +1,136 @@
+package handlers_test
+
+import (
+	"context"
+	"strconv"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/patch"
+	"temp-kbtg-backend/problem"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper function to PATCH a user with the given patch format and If-Match header
+func (s *testServer) patchUser(t *testing.T, id int, token, contentType, ifMatch, body string) testResponse {
+	t.Helper()
+	return s.do(t, fiber.MethodPatch, "/users/"+strconv.Itoa(id), token, map[string]string{
+		fiber.HeaderContentType: contentType,
+		fiber.HeaderIfMatch:     ifMatch,
+	}, body)
+}
+
+func TestPatchUserAppliesMergePatch(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createMember(t, "somchai@example.com", 100)
+	token := s.token(t, strconv.Itoa(user.ID), auth.RoleMember)
+
+	res := s.patchUser(t, user.ID, token, patch.MergePatchType, `"1"`, `{"last_name": "ใจดีมาก"}`)
+	if res.status != fiber.StatusOK {
+		t.Fatalf("got %d: %v", res.status, res.body)
+	}
+	if etag := res.header.Get(fiber.HeaderETag); etag != `"2"` {
+		t.Errorf("ETag is %s, want \"2\"", etag)
+	}
+
+	got, _ := s.store.Users().GetByID(context.Background(), user.ID)
+	if got.LastName != "ใจดีมาก" || got.FirstName != user.FirstName || got.Version != 2 {
+		t.Errorf("user is %+v", got)
+	}
+}
+
+func TestPatchUserAppliesJSONPatch(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createMember(t, "somchai@example.com", 100)
+	token := s.token(t, strconv.Itoa(user.ID), auth.RoleMember)
+
+	res := s.patchUser(t, user.ID, token, patch.JSONPatchType, `"1"`, `[
+		{"op": "test", "path": "/email", "value": "somchai@example.com"},
+		{"op": "replace", "path": "/email", "value": "somchai.j@example.com"}
+	]`)
+	if res.status != fiber.StatusOK {
+		t.Fatalf("got %d: %v", res.status, res.body)
+	}
+	if got, _ := s.store.Users().GetByID(context.Background(), user.ID); got.Email != "somchai.j@example.com" {
+		t.Errorf("email is %s", got.Email)
+	}
+}
+
+func TestPatchUserRejectsInvalidPatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+		field       string
+	}{
+		// No field can be cleared, null removes a member and the document is then invalid
+		{"merge null on a required field", patch.MergePatchType, `{"last_name": null}`, fiber.StatusBadRequest, problem.CodeValidation, "last_name"},
+		{"merge null on membership level", patch.MergePatchType, `{"membership_level": null}`, fiber.StatusBadRequest, problem.CodeValidation, "membership_level"},
+		{"remove a required field", patch.JSONPatchType, `[{"op": "remove", "path": "/email"}]`, fiber.StatusBadRequest, problem.CodeValidation, "email"},
+
+		{"failed test", patch.JSONPatchType, `[
+			{"op": "test", "path": "/email", "value": "someone@example.com"},
+			{"op": "replace", "path": "/email", "value": "somchai.j@example.com"}
+		]`, fiber.StatusConflict, "PATCH_TEST_FAILED", ""},
+		{"missing path", patch.JSONPatchType, `[{"op": "replace", "path": "/nickname", "value": "ชาย"}]`, fiber.StatusUnprocessableEntity, "PATCH_NOT_APPLICABLE", ""},
+		{"missing parent", patch.JSONPatchType, `[{"op": "add", "path": "/address/city", "value": "Bangkok"}]`, fiber.StatusUnprocessableEntity, "PATCH_NOT_APPLICABLE", ""},
+
+		{"read-only field", patch.JSONPatchType, `[{"op": "replace", "path": "/status", "value": "frozen"}]`, fiber.StatusBadRequest, problem.CodeValidation, "status"},
+		{"read-only field by merge", patch.MergePatchType, `{"membership_id": "LBK999999"}`, fiber.StatusBadRequest, problem.CodeValidation, "membership_id"},
+		{"unknown field", patch.MergePatchType, `{"nickname": "ชาย"}`, fiber.StatusBadRequest, problem.CodeValidation, "nickname"},
+		{"wrong type", patch.MergePatchType, `{"points": "many"}`, fiber.StatusBadRequest, problem.CodeValidation, "points"},
+
+		{"malformed patch", patch.JSONPatchType, `[{"op": "rename", "path": "/email"}]`, fiber.StatusBadRequest, "INVALID_PATCH", ""},
+		{"unsupported format", fiber.MIMEApplicationJSON, `{"last_name": "ใจดีมาก"}`, fiber.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			user := s.createMember(t, "somchai@example.com", 100)
+			token := s.token(t, "admin-1", auth.RoleAdmin)
+
+			res := s.patchUser(t, user.ID, token, tt.contentType, `"1"`, tt.body)
+			expectProblem(t, res, tt.status, tt.code)
+			if tt.field != "" && !hasFieldError(res, tt.field) {
+				t.Errorf("no error for %s: %v", tt.field, res.body["errors"])
+			}
+
+			// A rejected patch leaves the user untouched
+			if got, _ := s.store.Users().GetByID(context.Background(), user.ID); got.Version != 1 {
+				t.Errorf("user changed to version %d", got.Version)
+			}
+		})
+	}
+}
+
+func TestPatchUserChecksRolesAndVersion(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createMember(t, "somchai@example.com", 100)
+	other := s.createMember(t, "somying@example.com", 100)
+	member := s.token(t, strconv.Itoa(user.ID), auth.RoleMember)
+
+	res := s.patchUser(t, user.ID, member, patch.MergePatchType, `"1"`, `{"membership_level": "Gold"}`)
+	expectProblem(t, res, fiber.StatusForbidden, auth.CodeForbidden)
+
+	res = s.patchUser(t, other.ID, member, patch.MergePatchType, `"1"`, `{"last_name": "ใจดีมาก"}`)
+	expectProblem(t, res, fiber.StatusForbidden, auth.CodeForbidden)
+
+	res = s.patchUser(t, user.ID, member, patch.MergePatchType, `"7"`, `{"last_name": "ใจดีมาก"}`)
+	expectProblem(t, res, fiber.StatusPreconditionFailed, problem.CodePreconditionFail)
+
+	res = s.patchUser(t, user.ID, member, patch.MergePatchType, "", `{"last_name": "ใจดีมาก"}`)
+	expectProblem(t, res, fiber.StatusPreconditionRequired, problem.CodePreconditionReq)
+}
+
+// Helper function to check whether a validation problem names a field
+func hasFieldError(res testResponse, field string) bool {
+	errs, _ := res.body["errors"].([]interface{})
+	for _, e := range errs {
+		if fe, ok := e.(map[string]interface{}); ok && fe["field"] == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+	"temp-kbtg-backend/repository"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSearchLength bounds the q parameter of GET /users
+const maxSearchLength = 100
+
+// errInvalidCursor is returned for a cursor naming an unknown sort field
+var errInvalidCursor = errors.New("invalid cursor")
+
+// userCursor is the JSON inside the opaque cursor of GET /users. It carries
+// the sort so a cursor cannot be replayed against a different order.
+type userCursor struct {
+	Sort  string          `json:"sort"`
+	Value json.RawMessage `json:"value"`
+	ID    int             `json:"id"`
+}
+
+// Helper function to parse the search, filter, sort and paging parameters of GET /users
+func (h *Handler) parseUserQuery(c *fiber.Ctx) (repository.UserFilter, repository.UserPage, error) {
+	var filter repository.UserFilter
+	var fieldErrors []problem.FieldError
+	invalid := func(field, message string) {
+		fieldErrors = append(fieldErrors, problem.FieldError{Field: field, Message: message})
+	}
+
+	// Filter by account status, closed accounts are hidden unless requested
+	filter.Status = models.UserStatus(c.Query("status"))
+	if filter.Status != "" && !filter.Status.IsValid() {
+		invalid("status", "must be one of active, frozen, suspended, closed")
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		if utf8.RuneCountInString(q) > maxSearchLength {
+			invalid("q", "must be at most "+strconv.Itoa(maxSearchLength)+" characters")
+		}
+		filter.Search = strings.Fields(q)
+	}
+
+	filter.MembershipLevel = c.Query("membershipLevel")
+	if filter.MembershipLevel != "" && !models.IsValidMembershipLevel(filter.MembershipLevel) {
+		invalid("membershipLevel", "must be one of Bronze, Silver, Gold")
+	}
+
+	for _, bound := range []struct {
+		name string
+		dest **int
+	}{{"minPoints", &filter.MinPoints}, {"maxPoints", &filter.MaxPoints}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		points, err := strconv.Atoi(value)
+		if err != nil || points < 0 {
+			invalid(bound.name, "must be a non-negative integer")
+			continue
+		}
+		*bound.dest = &points
+	}
+	if filter.MinPoints != nil && filter.MaxPoints != nil && *filter.MinPoints > *filter.MaxPoints {
+		invalid("maxPoints", "must not be less than minPoints")
+	}
+
+	// Dates are whole UTC days, joinedTo includes the day itself
+	if value := c.Query("joinedFrom"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			invalid("joinedFrom", "must be a date (YYYY-MM-DD)")
+		}
+		filter.JoinedFrom = day
+	}
+	if value := c.Query("joinedTo"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			invalid("joinedTo", "must be a date (YYYY-MM-DD)")
+		} else {
+			filter.JoinedBefore = day.AddDate(0, 0, 1)
+		}
+	}
+	if !filter.JoinedFrom.IsZero() && !filter.JoinedBefore.IsZero() && !filter.JoinedFrom.Before(filter.JoinedBefore) {
+		invalid("joinedTo", "must not be before joinedFrom")
+	}
+
+	page := repository.UserPage{Sort: repository.UserSortID, Desc: true}
+	sortParam := c.Query("sort")
+	if sortParam != "" {
+		page.Sort = repository.UserSortField(strings.TrimPrefix(sortParam, "-"))
+		page.Desc = strings.HasPrefix(sortParam, "-")
+		if !page.Sort.IsValid() {
+			invalid("sort", "must be one of id, membership_id, first_name, last_name, points, joined_date, optionally prefixed with -")
+		}
+	}
+
+	number, pageSize := h.pageParams(c)
+	page.Limit = pageSize
+	page.Offset = (number - 1) * pageSize
+
+	if value := c.Query("cursor"); value != "" {
+		if c.Query("page") != "" {
+			invalid("cursor", "cannot be combined with page")
+		}
+		cursor, sort, err := decodeUserCursor(value)
+		switch {
+		case err != nil:
+			invalid("cursor", "is not a valid cursor")
+		case sortParam != "" && sort != sortParam:
+			invalid("cursor", "was issued for a different sort")
+		default:
+			page.Sort = repository.UserSortField(strings.TrimPrefix(sort, "-"))
+			page.Desc = strings.HasPrefix(sort, "-")
+			page.After = &cursor
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return filter, page, problem.Validation("Invalid query parameters", fieldErrors...)
+	}
+	return filter, page, nil
+}
+
+// Helper function to build the cursor that continues after the given user
+func encodeUserCursor(page repository.UserPage, last models.User) string {
+	sort := string(page.Sort)
+	if page.Desc {
+		sort = "-" + sort
+	}
+	value, _ := json.Marshal(page.Sort.Value(last))
+	data, _ := json.Marshal(userCursor{Sort: sort, Value: value, ID: last.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Helper function to decode a cursor into its position and sort
+func decodeUserCursor(encoded string) (repository.UserCursor, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return repository.UserCursor{}, "", err
+	}
+	var cursor userCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return repository.UserCursor{}, "", err
+	}
+
+	field := repository.UserSortField(strings.TrimPrefix(cursor.Sort, "-"))
+	if !field.IsValid() {
+		return repository.UserCursor{}, "", errInvalidCursor
+	}
+
+	// Decode the value into the type UserSortField.Value returns for the field
+	var value interface{}
+	switch field {
+	case repository.UserSortID, repository.UserSortPoints:
+		var number int
+		err = json.Unmarshal(cursor.Value, &number)
+		value = number
+	case repository.UserSortJoinedDate:
+		var at time.Time
+		err = json.Unmarshal(cursor.Value, &at)
+		value = at
+	default:
+		var text string
+		err = json.Unmarshal(cursor.Value, &text)
+		value = text
+	}
+	if err != nil {
+		return repository.UserCursor{}, "", err
+	}
+	return repository.UserCursor{Value: value, ID: cursor.ID}, cursor.Sort, nil
+}
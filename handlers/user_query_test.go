@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"testing"
+	"time"
+)
+
+func TestUserCursorRoundTrip(t *testing.T) {
+	bangkok := time.FixedZone("ICT", 7*60*60)
+	last := models.User{
+		ID:           42,
+		MembershipID: "LBK100042",
+		FirstName:    "สมชาย",
+		LastName:     "ใจดี",
+		Points:       1500,
+		JoinedDate:   time.Date(2026, 10, 19, 9, 30, 15, 123456789, bangkok),
+	}
+
+	for _, field := range []repository.UserSortField{
+		repository.UserSortID, repository.UserSortMembershipID, repository.UserSortFirstName,
+		repository.UserSortLastName, repository.UserSortPoints, repository.UserSortJoinedDate,
+	} {
+		for _, desc := range []bool{false, true} {
+			sort := string(field)
+			if desc {
+				sort = "-" + sort
+			}
+			t.Run(sort, func(t *testing.T) {
+				encoded := encodeUserCursor(repository.UserPage{Sort: field, Desc: desc}, last)
+				cursor, gotSort, err := decodeUserCursor(encoded)
+				if err != nil {
+					t.Fatalf("decode %s: %v", encoded, err)
+				}
+				if gotSort != sort || cursor.ID != last.ID {
+					t.Errorf("got sort %q and ID %d, want %q and %d", gotSort, cursor.ID, sort, last.ID)
+				}
+
+				// The value comes back as the type UserSortField.Value returns
+				want := field.Value(last)
+				if at, ok := want.(time.Time); ok {
+					if got, ok := cursor.Value.(time.Time); !ok || !got.Equal(at) {
+						t.Errorf("value is %#v, want %v", cursor.Value, at)
+					}
+				} else if cursor.Value != want {
+					t.Errorf("value is %#v, want %#v", cursor.Value, want)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeUserCursorRejectsTampering(t *testing.T) {
+	encode := func(json string) string { return base64.RawURLEncoding.EncodeToString([]byte(json)) }
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"not base64", "not a cursor!"},
+		{"not JSON", encode(`sort=id&id=1`)},
+		{"unknown sort field", encode(`{"sort":"password","value":"x","id":1}`)},
+		{"empty sort field", encode(`{"sort":"","value":1,"id":1}`)},
+		{"text for a number field", encode(`{"sort":"points","value":"1; DROP TABLE users","id":1}`)},
+		{"number for a text field", encode(`{"sort":"last_name","value":5,"id":1}`)},
+		{"malformed date", encode(`{"sort":"-joined_date","value":"yesterday","id":1}`)},
+		{"text for the ID", encode(`{"sort":"id","value":1,"id":"1"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, sort, err := decodeUserCursor(tt.encoded); err == nil {
+				t.Errorf("decoded %+v with sort %q", cursor, sort)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/problem"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// thaiPhonePattern matches Thai mobile (08x-xxx-xxxx) and landline
+// (02-xxx-xxxx) numbers, with a leading 0 or +66, once separators are removed
+var thaiPhonePattern = regexp.MustCompile(`^(?:0|\+66)(?:[689]\d{8}|[2-7]\d{7})$`)
+
+// validate evaluates the `validate` tags of the request models. Fields are
+// reported by their JSON name.
+var validate = newValidator()
+
+// Helper function to create the validator with the custom rules of the API
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("thai_phone", func(fl validator.FieldLevel) bool {
+		phone := strings.NewReplacer("-", "", " ", "").Replace(fl.Field().String())
+		return thaiPhonePattern.MatchString(phone)
+	})
+	v.RegisterValidation("membership_level", func(fl validator.FieldLevel) bool {
+		return models.IsValidMembershipLevel(fl.Field().String())
+	})
+	v.RegisterValidation("user_status", func(fl validator.FieldLevel) bool {
+		return models.UserStatus(fl.Field().String()).IsValid()
+	})
+	return v
+}
+
+// Helper function to parse the request body into out and validate it
+func parseBody(c *fiber.Ctx, out interface{}) error {
+	if err := c.BodyParser(out); err != nil {
+		return invalidBody(err)
+	}
+	return validateRequest(out)
+}
+
+// Helper function to validate a parsed request, returning a problem that lists every invalid field
+func validateRequest(req interface{}) error {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return problem.Internal("Failed to validate request", err)
+	}
+	fields := make([]problem.FieldError, 0, len(invalid))
+	for _, fieldErr := range invalid {
+		fields = append(fields, problem.FieldError{
+			Field:   fieldErr.Field(),
+			Message: fieldMessage(fieldErr),
+		})
+	}
+	return problem.Validation("Request validation failed", fields...)
+}
+
+// Helper function to describe a failed rule in plain English
+func fieldMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return "must be at least " + fieldErr.Param() + " characters"
+		}
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return "must be at most " + fieldErr.Param() + " characters"
+		}
+		return "must be at most " + fieldErr.Param()
+	case "thai_phone":
+		return "must be a Thai phone number, e.g. 081-234-5678"
+	case "membership_level":
+		return "must be one of " + models.LevelBronze + ", " + models.LevelSilver + ", " + models.LevelGold
+	case "user_status":
+		return "must be one of active, frozen, suspended, closed"
+	}
+	return "is invalid (" + fieldErr.Tag() + ")"
+}
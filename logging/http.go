@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"temp-kbtg-backend/problem"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is read from the request and echoed on every response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds caller-supplied request IDs
+const maxRequestIDLength = 128
+
+// Middleware assigns every request an ID, taken from X-Request-ID when the
+// caller sent a valid one, and writes one access log record per request.
+// Requests for which skipLog returns true are not logged.
+func Middleware(skipLog func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+		ctx := WithRequestID(c.UserContext(), requestID)
+		c.SetUserContext(ctx)
+		c.Set(RequestIDHeader, requestID)
+
+		err := c.Next()
+
+		// Errors returned by handlers get their status from the error handler later
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = problem.Status(err)
+		}
+
+		if skipLog != nil && skipLog(c) {
+			return err
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slog.LogAttrs(ctx, level, "request", attrs...)
+		return err
+	}
+}
+
+// Helper function to accept only short, printable request IDs from callers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
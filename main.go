@@ -1,13 +1,36 @@
 package main
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/config"
 	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/database/migrations"
 	"temp-kbtg-backend/handlers"
+	"temp-kbtg-backend/logging"
+	"temp-kbtg-backend/metrics"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/ratelimit"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/repository/postgres"
+	"temp-kbtg-backend/repository/sqlite"
+	"temp-kbtg-backend/rules"
+	"temp-kbtg-backend/tracing"
+	"temp-kbtg-backend/worker"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 
 	_ "temp-kbtg-backend/docs"
 
@@ -24,6 +47,10 @@ import (
 // @description - Idempotency Support
 // @description - Point Ledger (Audit Trail)
 // @description - Transaction Safety
+// @description - Fraud & Velocity Rules (Review Queue)
+// @description - Account Status (active, frozen, suspended, closed)
+// @description - Partner API (API keys, HMAC-SHA256 request signing)
+// @description - Rate Limiting (429 with RateLimit headers)
 
 // @contact.name KBTG Team
 // @contact.email support@kbtg.com
@@ -35,48 +62,447 @@ import (
 // @BasePath /
 // @schemes http
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description ใส่ "Bearer <token>" ขอ token สำหรับทดสอบได้จาก POST /auth/token (เมื่อเปิด auth.dev_login)
+
 func main() {
+	integrityReport := flag.Bool("integrity-report", false, "print the database integrity repair report as JSON and exit")
+	configFlags := config.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		logging.Setup("info")
+		logging.Fatal("Invalid configuration", err)
+	}
+	logging.Setup(cfg.LogLevel)
+
+	// Migration 0006 and ranked search need FTS5, which go-sqlite3 only compiles
+	// in with the tag. Without it search falls back to LIKE, and a database that
+	// already has the index is refused as newer than the binary.
+	if cfg.Database.Driver == "sqlite" && !migrations.FullTextSearch {
+		slog.Warn("Binary built without the sqlite_fts5 tag, user search is unranked and uses LIKE; build with make build or go build -tags sqlite_fts5")
+	}
+
+	if *integrityReport {
+		os.Exit(printIntegrityReport(cfg))
+	}
+
+	// migrate up | down [n] | status manages the schema without starting the server
+	if flag.Arg(0) == "migrate" {
+		os.Exit(runMigrate(cfg, flag.Args()[1:]))
+	}
+
+	slog.Info("Effective configuration", "config", cfg)
+
+	// Tracing is set up before the database so SQL statements are traced
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", err)
+	}
+
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		logging.Fatal("Failed to initialize authentication", err)
+	}
+
 	// Initialize database
-	if err := database.InitDB(); err != nil {
-		log.Fatal("Failed to initialize database:", err)
+	store, db, closeStore, err := openStore(cfg)
+	if err != nil {
+		logging.Fatal("Failed to initialize database", err)
 	}
-	defer database.CloseDB()
+
+	// Background jobs are stopped and drained before the database is closed
+	workers := worker.NewGroup()
+	if cfg.Database.Driver == "sqlite" {
+		workers.Every("wal-checkpoint", walCheckpointInterval, func(ctx context.Context) {
+			if err := database.Checkpoint(ctx, "PASSIVE"); err != nil {
+				slog.WarnContext(ctx, "WAL checkpoint failed", "error", err)
+			}
+		})
+	}
+
+	// Repository calls are timed and exported on /metrics
+	store = metrics.InstrumentStore(store)
+	metrics.WatchPoints(store)
+
+	// Nonces of signed partner requests are only needed while their timestamp is accepted
+	workers.Every("nonce-purge", noncePurgeInterval, func(ctx context.Context) {
+		before := time.Now().Add(-auth.NonceRetention(cfg.Auth.SignatureMaxSkew))
+		if _, err := store.APIKeys().PurgeNonces(ctx, before); err != nil {
+			slog.WarnContext(ctx, "Nonce purge failed", "error", err)
+		}
+	})
+
+	// Rate limit buckets are kept apart from the application database
+	limiter, err := ratelimit.Open(cfg.RateLimit)
+	if err != nil {
+		logging.Fatal("Failed to initialize rate limiting", err)
+	}
+	closeDatabase := closeStore
+	closeStore = func() {
+		limiter.Close()
+		closeDatabase()
+	}
+	workers.Every("rate-limit-purge", rateLimitPurgeInterval, func(ctx context.Context) {
+		if _, err := limiter.Purge(ctx); err != nil {
+			slog.WarnContext(ctx, "Rate limit purge failed", "error", err)
+		}
+	})
+
+	// Handlers read and write through the repositories of the selected backend
+	h := handlers.New(store, authenticator, cfg.Pagination, rules.New(cfg.Rules))
+	addReadinessChecks(h, db, migrations.Dialect(cfg.Database.Driver), workers)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
-		AppName: "KBTG Backend API",
+		AppName:               "KBTG Backend API",
+		DisableStartupMessage: true,
+		ErrorHandler:          handlers.ErrorHandler,
 	})
 
-	// Middleware
-	app.Use(logger.New())
+	// Middleware (probes are kept out of the request log)
+	app.Use(tracing.Middleware())
+	app.Use(logging.Middleware(func(c *fiber.Ctx) bool {
+		return probePaths[c.Path()]
+	}))
+	app.Use(metrics.Middleware())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept",
+		AllowOrigins:  strings.Join(cfg.CORS.AllowOrigins, ","),
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, If-Match, If-None-Match, " + logging.RequestIDHeader + ", " + strings.Join([]string{auth.HeaderAPIKey, auth.HeaderTimestamp, auth.HeaderNonce, auth.HeaderSignature}, ", "),
+		ExposeHeaders: "ETag, " + logging.RequestIDHeader + ", " + tracing.TraceIDHeader + ", RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After",
 	}))
+	// Every caller is limited per IP before its token or signature is checked,
+	// so guessing credentials is throttled like any other traffic
+	app.Use(limiter.IPMiddleware("ip", cfg.RateLimit.IP, unlimitedPath))
+	app.Use(auth.Middleware(authenticator))
+
+	// Roles allowed per route, members are further limited to their own account by the handlers
+	anyRole := auth.Require(auth.RoleMember, auth.RoleSupport, auth.RoleAdmin)
+	staff := auth.Require(auth.RoleSupport, auth.RoleAdmin)
+	admin := auth.Require(auth.RoleAdmin)
+
+	// Rate limits per route group and caller, applied after authentication so
+	// they key on the user or API key, and before the role checks so callers
+	// rejected with 403 are counted too
+	read := limiter.Middleware("read", cfg.RateLimit.Read)
+	write := limiter.Middleware("write", cfg.RateLimit.Write)
 
 	// Routes
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Hello, World!")
 	})
 
+	// Health & build info routes (for the orchestrator)
+	app.Get("/healthz", h.Healthz)
+	app.Get("/readyz", h.Readyz)
+	app.Get("/version", h.Version)
+	app.Get("/metrics", metrics.Handler())
+
+	// Token issuance without a password, for local development only
+	if cfg.Auth.DevLogin {
+		slog.Warn("Dev login is enabled, anyone can get a token from POST /auth/token")
+		app.Post("/auth/token", limiter.Middleware("auth", cfg.RateLimit.Auth), h.IssueDevToken)
+	}
+
 	// User routes
-	app.Get("/users", handlers.GetAllUsers)
-	app.Get("/users/:id", handlers.GetUserByID)
-	app.Post("/users", handlers.CreateUser)
-	app.Put("/users/:id", handlers.UpdateUser)
-	app.Delete("/users/:id", handlers.DeleteUser)
+	app.Get("/users", read, staff, h.GetAllUsers)
+	app.Get("/users/search", read, staff, h.SearchUsers)
+	app.Get("/users/:id", read, anyRole, h.GetUserByID)
+	app.Post("/users", write, staff, h.CreateUser)
+	app.Put("/users/:id", write, anyRole, h.UpdateUser)
+	app.Patch("/users/:id", write, anyRole, h.PatchUser)
+	app.Delete("/users/:id", write, admin, h.DeleteUser)
 
 	// Transfer routes (Points Transfer API)
-	app.Post("/transfers", handlers.CreateTransfer)
-	app.Get("/transfers/:id", handlers.GetTransferByID)
-	app.Get("/transfers", handlers.GetTransfers)
+	app.Post("/transfers", write, auth.Require(auth.RoleMember, auth.RoleAdmin), h.CreateTransfer)
+	app.Get("/transfers/:id", read, anyRole, h.GetTransferByID)
+	app.Get("/transfers", read, anyRole, h.GetTransfers)
+
+	// Admin routes (Account status)
+	app.Put("/admin/users/:id/status", write, staff, h.ChangeUserStatus)
+	app.Get("/admin/users/:id/status-history", read, staff, h.GetUserStatusHistory)
+
+	// Admin routes (Transfer review queue)
+	app.Get("/admin/transfer-reviews", read, staff, h.GetTransferReviews)
+	app.Post("/admin/transfer-reviews/:id/approve", write, admin, h.ApproveTransferReview)
+	app.Post("/admin/transfer-reviews/:id/reject", write, admin, h.RejectTransferReview)
+
+	// Admin routes (Partner API keys)
+	app.Post("/admin/api-keys", write, admin, h.CreateAPIKey)
+	app.Get("/admin/api-keys", read, admin, h.GetAPIKeys)
+	app.Post("/admin/api-keys/:id/rotate", write, admin, h.RotateAPIKey)
+	app.Delete("/admin/api-keys/:id", write, admin, h.RevokeAPIKey)
+
+	// Admin routes (Audit log)
+	app.Get("/admin/audit", read, admin, h.GetAuditLog)
+
+	// Partner routes, signed with an API key instead of a bearer token and limited per key once the signature is verified
+	partner := app.Group("/partner", auth.PartnerMiddleware(authenticator, store, cfg.Auth.SignatureMaxSkew), limiter.Middleware("partner", cfg.RateLimit.Partner))
+	partner.Post("/earn", auth.RequireScope(models.ScopeEarn), h.PartnerEarn)
+	partner.Post("/redeem", auth.RequireScope(models.ScopeRedeem), h.PartnerRedeem)
+	partner.Get("/users/:id/balance", auth.RequireScope(models.ScopeReadBalance), h.PartnerBalance)
 
 	// Swagger documentation
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
 	// Start server
-	log.Println("🚀 Server starting on port 3000")
-	if err := app.Listen(":3000"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	slog.Info("Server starting", "listen_addr", cfg.ListenAddr)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- app.Listen(cfg.ListenAddr)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		slog.Error("Failed to start server", "error", err)
+		exitCode = 1
+	case sig := <-quit:
+		slog.Info("Shutting down", "signal", sig.String(), "timeout", cfg.ShutdownTimeout.String())
+	}
+
+	if !shutdown(app, workers, closeStore, shutdownTracing, cfg.ShutdownTimeout) {
+		exitCode = 1
+	}
+	os.Exit(exitCode)
+}
+
+// probePaths are polled by the orchestrator and Prometheus and kept out of the request log
+var probePaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/version": true,
+	"/metrics": true,
+}
+
+// Helper function to report the routes that are not rate limited: the root,
+// the probes and the Swagger UI
+func unlimitedPath(c *fiber.Ctx) bool {
+	path := c.Path()
+	return path == "/" || probePaths[path] || strings.HasPrefix(path, "/swagger/")
+}
+
+// Helper function to register the /readyz checks: the database answers, its
+// schema is at the latest migration, and the background workers are running
+func addReadinessChecks(h *handlers.Handler, db *sql.DB, dialect migrations.Dialect, workers *worker.Group) {
+	h.AddReadinessCheck("database", db.PingContext)
+
+	migrator, err := migrations.New(db, dialect)
+	h.AddReadinessCheck("migrations", func(ctx context.Context) error {
+		if err != nil {
+			return err
+		}
+		current, err := migrator.Current(ctx)
+		if err != nil {
+			return err
+		}
+		if current != migrator.Latest() {
+			return fmt.Errorf("schema is at version %d, expected %d", current, migrator.Latest())
+		}
+		return nil
+	})
+
+	h.AddReadinessCheck("workers", func(ctx context.Context) error {
+		return workers.Check()
+	})
+}
+
+// walCheckpointInterval is how often the WAL is copied back into users.db while running
+const walCheckpointInterval = 5 * time.Minute
+
+// noncePurgeInterval is how often expired partner request nonces are deleted
+const noncePurgeInterval = 10 * time.Minute
+
+// rateLimitPurgeInterval is how often refilled rate limit buckets are forgotten
+const rateLimitPurgeInterval = 10 * time.Minute
+
+// Helper function to stop accepting requests, wait for in-flight handlers and
+// background workers, then close the database and flush spans. Returns false if the timeout expired.
+func shutdown(app *fiber.App, workers *worker.Group, closeStore func(), shutdownTracing func(context.Context) error, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	clean := true
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		slog.Error("HTTP server did not drain in time", "error", err)
+		clean = false
+	}
+	if err := workers.Shutdown(ctx); err != nil {
+		slog.Error("Background workers did not stop in time", "error", err)
+		clean = false
+	}
+
+	// Closing under a running transaction would fail it, so only close after a clean drain
+	if clean {
+		closeStore()
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+		clean = false
+	}
+	if clean {
+		slog.Info("Shutdown complete")
+	}
+	return clean
+}
+
+// Helper function to open the configured storage backend
+// and return its connection pool for health checks
+func openStore(cfg config.Config) (repository.Store, *sql.DB, func(), error) {
+	switch cfg.Database.Driver {
+	case "sqlite":
+		if err := database.InitDB(cfg.Database.DSN, cfg.SeedSampleData); err != nil {
+			return nil, nil, nil, err
+		}
+		return sqlite.New(database.DB), database.DB, database.CloseDB, nil
+	case "postgres":
+		db, err := postgres.Open(cfg.Database.DSN)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err = postgres.Init(db); err != nil {
+			db.Close()
+			return nil, nil, nil, err
+		}
+		slog.Info("PostgreSQL database initialized")
+		return postgres.New(db), db, func() {
+			db.Close()
+			slog.Info("Database connection closed")
+		}, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown database driver %q, expected sqlite or postgres", cfg.Database.Driver)
+	}
+}
+
+// Helper function to open the configured database without migrating it
+func openMigrator(cfg config.Config) (*migrations.Migrator, func(), error) {
+	var db *sql.DB
+	var dialect migrations.Dialect
+	switch cfg.Database.Driver {
+	case "sqlite":
+		if err := database.Open(cfg.Database.DSN); err != nil {
+			return nil, nil, err
+		}
+		db, dialect = database.DB, migrations.SQLite
+	case "postgres":
+		var err error
+		if db, err = postgres.Open(cfg.Database.DSN); err != nil {
+			return nil, nil, err
+		}
+		dialect = migrations.Postgres
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver %q, expected sqlite or postgres", cfg.Database.Driver)
+	}
+
+	migrator, err := migrations.New(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return migrator, func() { db.Close() }, nil
+}
+
+// Helper function to run the migrate subcommand
+func runMigrate(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: migrate up | down [n] | status")
+		return 2
+	}
+
+	migrator, closeDB, err := openMigrator(cfg)
+	if err != nil {
+		slog.Error("Failed to open database", "error", err)
+		return 1
+	}
+	defer closeDB()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			slog.Error("Migration failed", "error", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, "down expects a positive number of migrations")
+				return 2
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			slog.Error("Rollback failed", "error", err)
+			return 1
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("no migrations to roll back")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			slog.Error("Failed to read migration status", "error", err)
+			return 1
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, appliedAt)
+		}
+		if err := migrator.CheckVersion(ctx); err != nil {
+			slog.Error("Database schema is newer than this binary", "error", err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q, expected up, down or status\n", args[0])
+		return 2
+	}
+	return 0
+}
+
+// Helper function to print the integrity repair report without starting the server
+func printIntegrityReport(cfg config.Config) int {
+	if cfg.Database.Driver != "sqlite" {
+		slog.Error("-integrity-report is only available for sqlite")
+		return 1
+	}
+	if err := database.Open(cfg.Database.DSN); err != nil {
+		slog.Error("Failed to open database", "error", err)
+		return 1
+	}
+	defer database.CloseDB()
+
+	report, err := database.CheckIntegrity()
+	if err != nil {
+		slog.Error("Integrity check failed", "error", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if !report.OK() {
+		return 1
 	}
+	return 0
 }
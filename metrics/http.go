@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"temp-kbtg-backend/problem"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware records the count and latency of every request. Routes are
+// labelled by their pattern (/users/:id), requests that match no route as
+// "unmatched", so the number of series stays bounded.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		// Errors returned by handlers get their status from the error handler later
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if err != nil {
+			status = problem.Status(err)
+			// The router reports a path without a route as a 404 error; the
+			// route seen here is then the last middleware, not a real route
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+				route = "unmatched"
+			}
+		}
+
+		labels := []string{c.Method(), route, strconv.Itoa(status)}
+		httpRequests.WithLabelValues(labels...).Inc()
+		httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
@@ -0,0 +1,155 @@
+// Package metrics defines the Prometheus metrics of the API and serves them
+// on /metrics. Collectors live in their own Registry so tests can read them
+// with prometheus/testutil without a running Prometheus server.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kbtg"
+
+// Registry holds every metric exported by the API
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Repository call latency by operation and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "outcome"})
+
+	transfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Transfers by the status they reached and the reason for failed ones.",
+	}, []string{"status", "reason"})
+
+	transferPoints = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_points_total",
+		Help:      "Points in transfers by the status they reached and the reason for failed ones.",
+	}, []string{"status", "reason"})
+
+	ledgerWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ledger_entries_total",
+		Help:      "Committed point ledger entries by event type.",
+	}, []string{"event_type"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected with 429 by route group.",
+	}, []string{"group"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		dbQueryDuration,
+		transfers,
+		transferPoints,
+		ledgerWrites,
+		rateLimited,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// Transfer reasons keep the reason label to a fixed set of values
+const (
+	ReasonNone           = ""
+	ReasonReviewRejected = "review_rejected"
+)
+
+// ObserveTransfer counts a transfer that reached status. For failed transfers
+// reason is the rule that denied it or ReasonReviewRejected.
+func ObserveTransfer(status models.TransferStatus, reason string, amount int) {
+	transfers.WithLabelValues(string(status), reason).Inc()
+	transferPoints.WithLabelValues(string(status), reason).Add(float64(amount))
+}
+
+// ObserveRateLimited counts a request rejected by the rate limit of group
+func ObserveRateLimited(group string) {
+	rateLimited.WithLabelValues(group).Inc()
+}
+
+// Helper function to count committed ledger entries
+func observeLedger(entries []models.PointLedger) {
+	for _, entry := range entries {
+		ledgerWrites.WithLabelValues(string(entry.EventType)).Inc()
+	}
+}
+
+// pointsTimeout bounds the balance query run on every scrape
+const pointsTimeout = 2 * time.Second
+
+// pointsCollector reports the points held by members, read from the store at scrape time
+type pointsCollector struct {
+	store repository.Store
+	desc  *prometheus.Desc
+}
+
+// WatchPoints exports the outstanding member points per account status
+func WatchPoints(store repository.Store) {
+	Registry.MustRegister(&pointsCollector{
+		store: store,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "points_outstanding"),
+			"Points held by member accounts (excluding the system account) by account status.",
+			[]string{"status"}, nil,
+		),
+	})
+}
+
+func (c *pointsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *pointsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), pointsTimeout)
+	defer cancel()
+
+	totals, err := c.store.Users().PointsByStatus(ctx)
+	if err != nil {
+		slog.Error("Failed to collect outstanding points", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	// Report every status so a drained status drops to 0 instead of disappearing
+	for _, status := range []models.UserStatus{models.UserActive, models.UserFrozen, models.UserSuspended, models.UserClosed} {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(totals[status]), string(status))
+	}
+}
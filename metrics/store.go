@@ -0,0 +1,292 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// InstrumentStore wraps a store so every repository call is timed and ledger
+// entries are counted once their transaction commits
+func InstrumentStore(store repository.Store) repository.Store {
+	return &instrumentedStore{inner: store}
+}
+
+type instrumentedStore struct {
+	inner repository.Store
+	// pending collects observations made inside WithinTx until it commits.
+	// It is nil outside a transaction, where writes are observed at once.
+	pending *[]func()
+}
+
+func (s *instrumentedStore) Users() repository.UserRepository {
+	return userRepository{inner: s.inner.Users()}
+}
+
+func (s *instrumentedStore) Transfers() repository.TransferRepository {
+	return transferRepository{inner: s.inner.Transfers()}
+}
+
+func (s *instrumentedStore) Ledger() repository.LedgerRepository {
+	return ledgerRepository{inner: s.inner.Ledger(), store: s}
+}
+
+func (s *instrumentedStore) APIKeys() repository.APIKeyRepository {
+	return apiKeyRepository{inner: s.inner.APIKeys()}
+}
+
+func (s *instrumentedStore) Audit() repository.AuditRepository {
+	return auditRepository{inner: s.inner.Audit()}
+}
+
+func (s *instrumentedStore) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	// A nested call joins the outer transaction and its pending observations
+	if s.pending != nil {
+		return s.inner.WithinTx(ctx, func(tx repository.Store) error {
+			return fn(&instrumentedStore{inner: tx, pending: s.pending})
+		})
+	}
+
+	var pending []func()
+	err := s.inner.WithinTx(ctx, func(tx repository.Store) error {
+		pending = nil
+		return fn(&instrumentedStore{inner: tx, pending: &pending})
+	})
+	if err != nil {
+		return err
+	}
+	for _, observe := range pending {
+		observe()
+	}
+	return nil
+}
+
+// Helper function to observe now, or after commit inside a transaction
+func (s *instrumentedStore) afterCommit(observe func()) {
+	if s.pending == nil {
+		observe()
+		return
+	}
+	*s.pending = append(*s.pending, observe)
+}
+
+// Helper function to time one repository call
+func timeQuery(operation string, start time.Time, err error) {
+	outcome := "ok"
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		outcome = "not_found"
+	case err != nil:
+		outcome = "error"
+	}
+	dbQueryDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+type userRepository struct {
+	inner repository.UserRepository
+}
+
+func (r userRepository) GetByID(ctx context.Context, id int) (user models.User, err error) {
+	defer func(start time.Time) { timeQuery("users.get_by_id", start, err) }(time.Now())
+	return r.inner.GetByID(ctx, id)
+}
+
+func (r userRepository) GetByIDForUpdate(ctx context.Context, id int) (user models.User, err error) {
+	defer func(start time.Time) { timeQuery("users.get_by_id_for_update", start, err) }(time.Now())
+	return r.inner.GetByIDForUpdate(ctx, id)
+}
+
+func (r userRepository) GetByMembershipID(ctx context.Context, membershipID string) (user models.User, err error) {
+	defer func(start time.Time) { timeQuery("users.get_by_membership_id", start, err) }(time.Now())
+	return r.inner.GetByMembershipID(ctx, membershipID)
+}
+
+func (r userRepository) List(ctx context.Context, filter repository.UserFilter, page repository.UserPage) (users []models.User, total int, err error) {
+	defer func(start time.Time) { timeQuery("users.list", start, err) }(time.Now())
+	return r.inner.List(ctx, filter, page)
+}
+
+func (r userRepository) Search(ctx context.Context, terms []string, limit, offset int) (hits []models.UserSearchHit, total int, err error) {
+	defer func(start time.Time) { timeQuery("users.search", start, err) }(time.Now())
+	return r.inner.Search(ctx, terms, limit, offset)
+}
+
+func (r userRepository) Create(ctx context.Context, user *models.User) (err error) {
+	defer func(start time.Time) { timeQuery("users.create", start, err) }(time.Now())
+	return r.inner.Create(ctx, user)
+}
+
+func (r userRepository) Update(ctx context.Context, id int, req models.UpdateUserRequest) (err error) {
+	defer func(start time.Time) { timeQuery("users.update", start, err) }(time.Now())
+	return r.inner.Update(ctx, id, req)
+}
+
+func (r userRepository) AdjustPoints(ctx context.Context, id int, delta int) (balance int, err error) {
+	defer func(start time.Time) { timeQuery("users.adjust_points", start, err) }(time.Now())
+	return r.inner.AdjustPoints(ctx, id, delta)
+}
+
+func (r userRepository) SetStatus(ctx context.Context, id int, status models.UserStatus, at time.Time) (err error) {
+	defer func(start time.Time) { timeQuery("users.set_status", start, err) }(time.Now())
+	return r.inner.SetStatus(ctx, id, status, at)
+}
+
+func (r userRepository) NextMembershipID(ctx context.Context) (id string, err error) {
+	defer func(start time.Time) { timeQuery("users.next_membership_id", start, err) }(time.Now())
+	return r.inner.NextMembershipID(ctx)
+}
+
+func (r userRepository) AddStatusChange(ctx context.Context, change *models.UserStatusChange) (err error) {
+	defer func(start time.Time) { timeQuery("users.add_status_change", start, err) }(time.Now())
+	return r.inner.AddStatusChange(ctx, change)
+}
+
+func (r userRepository) ListStatusChanges(ctx context.Context, userID int) (changes []models.UserStatusChange, err error) {
+	defer func(start time.Time) { timeQuery("users.list_status_changes", start, err) }(time.Now())
+	return r.inner.ListStatusChanges(ctx, userID)
+}
+
+func (r userRepository) PointsByStatus(ctx context.Context) (totals map[models.UserStatus]int, err error) {
+	defer func(start time.Time) { timeQuery("users.points_by_status", start, err) }(time.Now())
+	return r.inner.PointsByStatus(ctx)
+}
+
+type transferRepository struct {
+	inner repository.TransferRepository
+}
+
+func (r transferRepository) Create(ctx context.Context, transfer *models.Transfer) (err error) {
+	defer func(start time.Time) { timeQuery("transfers.create", start, err) }(time.Now())
+	return r.inner.Create(ctx, transfer)
+}
+
+func (r transferRepository) GetByIdemKey(ctx context.Context, idemKey string) (transfer models.Transfer, err error) {
+	defer func(start time.Time) { timeQuery("transfers.get_by_idem_key", start, err) }(time.Now())
+	return r.inner.GetByIdemKey(ctx, idemKey)
+}
+
+func (r transferRepository) ListByUser(ctx context.Context, userID, limit, offset int) (transfers []models.Transfer, total int, err error) {
+	defer func(start time.Time) { timeQuery("transfers.list_by_user", start, err) }(time.Now())
+	return r.inner.ListByUser(ctx, userID, limit, offset)
+}
+
+func (r transferRepository) UpdateStatus(ctx context.Context, id int, status models.TransferStatus, at time.Time, failReason *string) (err error) {
+	defer func(start time.Time) { timeQuery("transfers.update_status", start, err) }(time.Now())
+	return r.inner.UpdateStatus(ctx, id, status, at, failReason)
+}
+
+func (r transferRepository) CountSentSince(ctx context.Context, fromUserID int, since time.Time) (count int, err error) {
+	defer func(start time.Time) { timeQuery("transfers.count_sent_since", start, err) }(time.Now())
+	return r.inner.CountSentSince(ctx, fromUserID, since)
+}
+
+func (r transferRepository) CountCompletedBetween(ctx context.Context, fromUserID, toUserID int) (count int, err error) {
+	defer func(start time.Time) { timeQuery("transfers.count_completed_between", start, err) }(time.Now())
+	return r.inner.CountCompletedBetween(ctx, fromUserID, toUserID)
+}
+
+func (r transferRepository) CountSendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (count int, err error) {
+	defer func(start time.Time) { timeQuery("transfers.count_senders_since", start, err) }(time.Now())
+	return r.inner.CountSendersSince(ctx, toUserID, excludeUserID, since)
+}
+
+func (r transferRepository) CreateReview(ctx context.Context, review *models.TransferReview) (err error) {
+	defer func(start time.Time) { timeQuery("transfers.create_review", start, err) }(time.Now())
+	return r.inner.CreateReview(ctx, review)
+}
+
+func (r transferRepository) GetReview(ctx context.Context, id int) (review models.TransferReview, err error) {
+	defer func(start time.Time) { timeQuery("transfers.get_review", start, err) }(time.Now())
+	return r.inner.GetReview(ctx, id)
+}
+
+func (r transferRepository) ListReviews(ctx context.Context, status models.ReviewStatus) (reviews []models.TransferReview, err error) {
+	defer func(start time.Time) { timeQuery("transfers.list_reviews", start, err) }(time.Now())
+	return r.inner.ListReviews(ctx, status)
+}
+
+func (r transferRepository) ResolveReview(ctx context.Context, id int, status models.ReviewStatus, note *string, at time.Time) (err error) {
+	defer func(start time.Time) { timeQuery("transfers.resolve_review", start, err) }(time.Now())
+	return r.inner.ResolveReview(ctx, id, status, note, at)
+}
+
+type ledgerRepository struct {
+	inner repository.LedgerRepository
+	store *instrumentedStore
+}
+
+func (r ledgerRepository) Append(ctx context.Context, entries ...models.PointLedger) (err error) {
+	defer func(start time.Time) { timeQuery("ledger.append", start, err) }(time.Now())
+	if err = r.inner.Append(ctx, entries...); err != nil {
+		return err
+	}
+	r.store.afterCommit(func() { observeLedger(entries) })
+	return nil
+}
+
+func (r ledgerRepository) ListByUser(ctx context.Context, userID int) (entries []models.PointLedger, err error) {
+	defer func(start time.Time) { timeQuery("ledger.list_by_user", start, err) }(time.Now())
+	return r.inner.ListByUser(ctx, userID)
+}
+
+type apiKeyRepository struct {
+	inner repository.APIKeyRepository
+}
+
+func (r apiKeyRepository) Create(ctx context.Context, key *models.APIKey) (err error) {
+	defer func(start time.Time) { timeQuery("api_keys.create", start, err) }(time.Now())
+	return r.inner.Create(ctx, key)
+}
+
+func (r apiKeyRepository) GetByID(ctx context.Context, id int) (key models.APIKey, err error) {
+	defer func(start time.Time) { timeQuery("api_keys.get_by_id", start, err) }(time.Now())
+	return r.inner.GetByID(ctx, id)
+}
+
+func (r apiKeyRepository) GetByKeyID(ctx context.Context, keyID string) (key models.APIKey, err error) {
+	defer func(start time.Time) { timeQuery("api_keys.get_by_key_id", start, err) }(time.Now())
+	return r.inner.GetByKeyID(ctx, keyID)
+}
+
+func (r apiKeyRepository) List(ctx context.Context) (keys []models.APIKey, err error) {
+	defer func(start time.Time) { timeQuery("api_keys.list", start, err) }(time.Now())
+	return r.inner.List(ctx)
+}
+
+func (r apiKeyRepository) Rotate(ctx context.Context, id int, secretHash string, at time.Time) (err error) {
+	defer func(start time.Time) { timeQuery("api_keys.rotate", start, err) }(time.Now())
+	return r.inner.Rotate(ctx, id, secretHash, at)
+}
+
+func (r apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) (err error) {
+	defer func(start time.Time) { timeQuery("api_keys.revoke", start, err) }(time.Now())
+	return r.inner.Revoke(ctx, id, at)
+}
+
+func (r apiKeyRepository) UseNonce(ctx context.Context, id int, nonce string, at time.Time) (err error) {
+	defer func(start time.Time) { timeQuery("api_keys.use_nonce", start, err) }(time.Now())
+	return r.inner.UseNonce(ctx, id, nonce, at)
+}
+
+func (r apiKeyRepository) PurgeNonces(ctx context.Context, before time.Time) (purged int, err error) {
+	defer func(start time.Time) { timeQuery("api_keys.purge_nonces", start, err) }(time.Now())
+	return r.inner.PurgeNonces(ctx, before)
+}
+
+type auditRepository struct {
+	inner repository.AuditRepository
+}
+
+func (r auditRepository) Append(ctx context.Context, entry *models.AuditEntry) (err error) {
+	defer func(start time.Time) { timeQuery("audit.append", start, err) }(time.Now())
+	return r.inner.Append(ctx, entry)
+}
+
+func (r auditRepository) List(ctx context.Context, filter repository.AuditFilter, limit, offset int) (entries []models.AuditEntry, total int, err error) {
+	defer func(start time.Time) { timeQuery("audit.list", start, err) }(time.Now())
+	return r.inner.List(ctx, filter, limit, offset)
+}
+
+var _ repository.Store = (*instrumentedStore)(nil)
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKeyScope is an operation a partner API key may perform
+type APIKeyScope string
+
+const (
+	ScopeEarn        APIKeyScope = "earn"         // ให้แต้มสมาชิก
+	ScopeRedeem      APIKeyScope = "redeem"       // ตัดแต้มสมาชิก
+	ScopeReadBalance APIKeyScope = "read-balance" // ดูยอดแต้มคงเหลือ
+)
+
+// APIKey is a partner (merchant) credential. The secret is not stored, it is
+// derived from SecretSalt and a pepper held by the server.
+type APIKey struct {
+	ID         int           `json:"id"`
+	KeyID      string        `json:"keyId"` // Public identifier sent in X-Api-Key
+	Name       string        `json:"name"`
+	SecretSalt string        `json:"-"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	RotatedAt  *time.Time    `json:"rotatedAt,omitempty"`
+	RevokedAt  *time.Time    `json:"revokedAt,omitempty"`
+}
+
+// HasScope reports whether the key grants the scope
+func (k APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinScopes stores scopes as a comma-separated list
+func JoinScopes(scopes []APIKeyScope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, ",")
+}
+
+// SplitScopes parses a list stored by JoinScopes
+func SplitScopes(value string) []APIKeyScope {
+	scopes := []APIKeyScope{}
+	for _, part := range strings.Split(value, ",") {
+		if part != "" {
+			scopes = append(scopes, APIKeyScope(part))
+		}
+	}
+	return scopes
+}
+
+// APIKeyCreateRequest creates a partner API key
+type APIKeyCreateRequest struct {
+	Name   string        `json:"name" validate:"required,max=100" example:"Cafe Amazon"`
+	Scopes []APIKeyScope `json:"scopes" validate:"required,min=1,dive,oneof=earn redeem read-balance" example:"earn,read-balance"`
+}
+
+// APIKeySecretResponse returns a key with its secret, which is shown only once
+type APIKeySecretResponse struct {
+	APIKey
+	Secret string `json:"secret" example:"sk_3f9a..."` // แสดงครั้งเดียว เก็บไว้ให้ดี
+}
+
+// APIKeyListResponse lists every API key, including revoked ones
+type APIKeyListResponse struct {
+	Data []APIKey `json:"data"`
+}
+
+// PartnerPointsRequest earns or redeems points for a member. Reference is
+// the partner's own ID for the operation and makes it idempotent.
+type PartnerPointsRequest struct {
+	UserID    int    `json:"userId" validate:"required,min=1" example:"1"`
+	Amount    int    `json:"amount" validate:"required,min=1" example:"50"`
+	Reference string `json:"reference" validate:"required,max=100" example:"order-20251019-0001"`
+}
+
+// PartnerPointsResponse is the result of an earn or redeem
+type PartnerPointsResponse struct {
+	UserID    int       `json:"userId"`
+	Change    int       `json:"change"`
+	Balance   int       `json:"balance"`
+	Reference string    `json:"reference"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PartnerBalanceResponse is a member's balance as seen by a partner
+type PartnerBalanceResponse struct {
+	UserID       int    `json:"userId"`
+	MembershipID string `json:"membershipId"`
+	Points       int    `json:"points"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// AuditAction names a data-changing operation recorded in the audit log
+type AuditAction string
+
+const (
+	AuditUserCreate       AuditAction = "user.create"
+	AuditUserUpdate       AuditAction = "user.update"
+	AuditUserClose        AuditAction = "user.close"
+	AuditUserStatusChange AuditAction = "user.status_change"
+	AuditTransferCreate   AuditAction = "transfer.create"
+	AuditReviewApprove    AuditAction = "transfer_review.approve"
+	AuditReviewReject     AuditAction = "transfer_review.reject"
+	AuditAPIKeyCreate     AuditAction = "api_key.create"
+	AuditAPIKeyRotate     AuditAction = "api_key.rotate"
+	AuditAPIKeyRevoke     AuditAction = "api_key.revoke"
+	AuditPartnerEarn      AuditAction = "partner.earn"
+	AuditPartnerRedeem    AuditAction = "partner.redeem"
+)
+
+// Entity types of the audit log
+const (
+	EntityUser           = "user"
+	EntityTransfer       = "transfer"
+	EntityTransferReview = "transfer_review"
+	EntityAPIKey         = "api_key"
+)
+
+// FieldChange is the value of one field before and after a change. Before
+// is null for created entities.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry records who changed which entity and how
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	Actor      string                 `json:"actor" example:"alice"`     // Token subject (user ID for members) or partner key ID
+	ActorRole  string                 `json:"actorRole" example:"admin"` // member, support, admin or partner
+	Action     AuditAction            `json:"action" example:"user.update"`
+	EntityType string                 `json:"entityType" example:"user"`
+	EntityID   int                    `json:"entityId" example:"1"`
+	Changes    map[string]FieldChange `json:"changes,omitempty"`
+	RequestID  string                 `json:"requestId,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+// AuditListResponse wraps a page of audit entries, newest first
+type AuditListResponse struct {
+	Data     []AuditEntry `json:"data"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"pageSize"`
+	Total    int          `json:"total"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TokenRequest asks the dev login endpoint for a token. Members need their
+// user ID, staff roles need a name.
+type TokenRequest struct {
+	Role   string `json:"role" validate:"required,oneof=member support admin" example:"member"`
+	UserID int    `json:"userId,omitempty" validate:"required_if=Role member,omitempty,min=1" example:"1"`
+	Name   string `json:"name,omitempty" validate:"required_unless=Role member,max=100" example:"alice"`
+}
+
+// TokenResponse carries an issued access token
+type TokenResponse struct {
+	AccessToken string    `json:"accessToken"`
+	TokenType   string    `json:"tokenType" example:"Bearer"`
+	ExpiresIn   int       `json:"expiresIn" example:"3600"` // Seconds until the token expires
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// HealthResponse is returned by /healthz while the process is alive
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status string `json:"status"`          // ok or failing
+	Error  string `json:"error,omitempty"` // Reason the check failed
+}
+
+// ReadinessResponse is returned by /readyz with the result of every check
+type ReadinessResponse struct {
+	Status string                 `json:"status"` // ready or not_ready
+	Checks map[string]CheckResult `json:"checks"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ReviewStatus represents the status of a transfer held for review
+type ReviewStatus string
+
+const (
+	ReviewOpen     ReviewStatus = "open"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// TransferReview represents a transfer routed to the review queue by a fraud rule
+type TransferReview struct {
+	ID             int          `json:"id"`
+	TransferID     int          `json:"transferId"`
+	Rule           string       `json:"rule"`                     // Rule that requested the review
+	Reason         string       `json:"reason"`                   // Reason reported by the rule
+	Status         ReviewStatus `json:"status"`                   // Review status
+	ResolutionNote *string      `json:"resolutionNote,omitempty"` // Note from the reviewer
+	CreatedAt      time.Time    `json:"createdAt"`
+	ResolvedAt     *time.Time   `json:"resolvedAt,omitempty"`
+	Transfer       *Transfer    `json:"transfer,omitempty"`
+}
+
+// ReviewResolveRequest represents the request to approve or reject a review
+type ReviewResolveRequest struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+// TransferReviewListResponse wraps the review queue
+type TransferReviewListResponse struct {
+	Data  []TransferReview `json:"data"`
+	Total int              `json:"total"`
+}
@@ -34,7 +34,7 @@ type TransferCreateRequest struct {
 	FromUserID int     `json:"fromUserId" validate:"required,min=1"`
 	ToUserID   int     `json:"toUserId" validate:"required,min=1"`
 	Amount     int     `json:"amount" validate:"required,min=1"`
-	Note       *string `json:"note,omitempty"`
+	Note       *string `json:"note,omitempty" validate:"omitempty,max=200"`
 }
 
 // TransferCreateResponse wraps the created transfer
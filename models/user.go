@@ -2,33 +2,157 @@ package models
 
 import "time"
 
+// SystemMembershipID identifies the internal account that receives forfeited points.
+// It is frozen so it can never send or receive regular transfers.
+const SystemMembershipID = "LBK000000"
+
+// UserStatus represents the status of a member account
+type UserStatus string
+
+const (
+	UserActive    UserStatus = "active"    // ใช้งานได้ปกติ
+	UserFrozen    UserStatus = "frozen"    // ระงับชั่วคราว ห้ามโอนออกและรับโอน
+	UserSuspended UserStatus = "suspended" // รับแต้มได้อย่างเดียว
+	UserClosed    UserStatus = "closed"    // ปิดบัญชีแล้ว
+)
+
+// userStatusTransitions lists the statuses each status may move to
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserActive:    {UserFrozen, UserSuspended, UserClosed},
+	UserFrozen:    {UserActive, UserSuspended, UserClosed},
+	UserSuspended: {UserActive, UserFrozen, UserClosed},
+	UserClosed:    {},
+}
+
+// IsValid reports whether the status is a known account status
+func (s UserStatus) IsValid() bool {
+	_, ok := userStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an account may move from s to next
+func (s UserStatus) CanTransitionTo(next UserStatus) bool {
+	for _, allowed := range userStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSend reports whether the account may send or redeem points
+func (s UserStatus) CanSend() bool {
+	return s == UserActive
+}
+
+// CanReceive reports whether the account may receive or earn points
+func (s UserStatus) CanReceive() bool {
+	return s == UserActive || s == UserSuspended
+}
+
+// Membership levels a user can hold
+const (
+	LevelBronze = "Bronze"
+	LevelSilver = "Silver"
+	LevelGold   = "Gold"
+)
+
+// IsValidMembershipLevel reports whether level is a known membership level
+func IsValidMembershipLevel(level string) bool {
+	return level == LevelBronze || level == LevelSilver || level == LevelGold
+}
+
 type User struct {
-	ID              int       `json:"id"`
-	MembershipID    string    `json:"membership_id"`    // รหัสสมาชิก เช่น LBK001234
-	FirstName       string    `json:"first_name"`       // ชื่อ
-	LastName        string    `json:"last_name"`        // นามสกุล
-	PhoneNumber     string    `json:"phone_number"`     // เบอร์โทรศัพท์
-	Email           string    `json:"email"`            // อีเมล
-	MembershipLevel string    `json:"membership_level"` // ระดับสมาชิก (Gold, Silver, Bronze)
-	Points          int       `json:"points"`           // แต้มคงเหลือ
-	JoinedDate      time.Time `json:"joined_date"`      // วันที่สมัครสมาชิก
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int        `json:"id"`
+	MembershipID    string     `json:"membership_id"`       // รหัสสมาชิก เช่น LBK001234
+	FirstName       string     `json:"first_name"`          // ชื่อ
+	LastName        string     `json:"last_name"`           // นามสกุล
+	PhoneNumber     string     `json:"phone_number"`        // เบอร์โทรศัพท์
+	Email           string     `json:"email"`               // อีเมล
+	MembershipLevel string     `json:"membership_level"`    // ระดับสมาชิก (Gold, Silver, Bronze)
+	Points          int        `json:"points"`              // แต้มคงเหลือ
+	Status          UserStatus `json:"status"`              // สถานะบัญชี (active, frozen, suspended, closed)
+	ClosedAt        *time.Time `json:"closed_at,omitempty"` // วันที่ปิดบัญชี
+	JoinedDate      time.Time  `json:"joined_date"`         // วันที่สมัครสมาชิก
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Version         int        `json:"version"` // เพิ่มขึ้นทุกครั้งที่ข้อมูลเปลี่ยน ใช้เป็น ETag
+}
+
+// UserListResponse wraps a page of users. NextCursor is set when more users
+// follow and continues the listing with keyset pagination.
+type UserListResponse struct {
+	Data       []User `json:"data"`
+	Page       int    `json:"page,omitempty"` // Not set when paging by cursor
+	PageSize   int    `json:"pageSize"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// UserSearchHit is a user found by GET /users/search. Highlights holds the
+// matching fields with every match wrapped in <mark></mark>, HTML-escaped.
+type UserSearchHit struct {
+	User
+	Score      float64           `json:"score"` // Relevance, higher is better. 0 when ranking is not available
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// UserSearchResponse wraps a page of search hits, best match first
+type UserSearchResponse struct {
+	Data     []UserSearchHit `json:"data"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+	Total    int             `json:"total"`
 }
 
 type CreateUserRequest struct {
-	FirstName       string `json:"first_name" validate:"required"`
-	LastName        string `json:"last_name" validate:"required"`
-	PhoneNumber     string `json:"phone_number" validate:"required"`
-	Email           string `json:"email" validate:"required,email"`
-	MembershipLevel string `json:"membership_level"` // Default: Bronze
+	FirstName       string `json:"first_name" validate:"required,max=100"`
+	LastName        string `json:"last_name" validate:"required,max=100"`
+	PhoneNumber     string `json:"phone_number" validate:"required,thai_phone"`
+	Email           string `json:"email" validate:"required,email,max=254"`
+	MembershipLevel string `json:"membership_level" validate:"omitempty,membership_level"` // Default: Bronze
 }
 
 type UpdateUserRequest struct {
-	FirstName       string `json:"first_name"`
-	LastName        string `json:"last_name"`
-	PhoneNumber     string `json:"phone_number"`
-	Email           string `json:"email"`
-	MembershipLevel string `json:"membership_level"`
-	Points          *int   `json:"points"` // pointer เพื่อให้แยกระหว่าง 0 กับ null
+	FirstName       string `json:"first_name" validate:"omitempty,max=100"`
+	LastName        string `json:"last_name" validate:"omitempty,max=100"`
+	PhoneNumber     string `json:"phone_number" validate:"omitempty,thai_phone"`
+	Email           string `json:"email" validate:"omitempty,email,max=254"`
+	MembershipLevel string `json:"membership_level" validate:"omitempty,membership_level"`
+	Points          *int   `json:"points" validate:"omitempty,min=0"` // pointer เพื่อให้แยกระหว่าง 0 กับ null
+}
+
+// UserPatchDocument holds the editable fields of a user once a PATCH has been
+// applied. Unlike UpdateUserRequest the whole document is validated and every
+// field is required, so a patch cannot clear a field with null or remove.
+type UserPatchDocument struct {
+	FirstName       string `json:"first_name" validate:"required,max=100"`
+	LastName        string `json:"last_name" validate:"required,max=100"`
+	PhoneNumber     string `json:"phone_number" validate:"required,thai_phone"`
+	Email           string `json:"email" validate:"required,email,max=254"`
+	MembershipLevel string `json:"membership_level" validate:"required,membership_level"`
+	Points          *int   `json:"points" validate:"required,min=0"`
+}
+
+// UserStatusChangeRequest represents the request to change an account status.
+// The actor is the caller's token subject, not part of the request.
+type UserStatusChangeRequest struct {
+	Status UserStatus `json:"status" validate:"required,user_status"`
+	Reason string     `json:"reason" validate:"required,max=500"`
+}
+
+// UserCloseRequest represents the optional body of DELETE /users/:id
+type UserCloseRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// UserStatusChange represents a recorded account status transition
+type UserStatusChange struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	FromStatus UserStatus `json:"from_status"`
+	ToStatus   UserStatus `json:"to_status"`
+	Reason     string     `json:"reason"`
+	Actor      string     `json:"actor"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
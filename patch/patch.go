@@ -0,0 +1,214 @@
+// Package patch applies RFC 7396 JSON Merge Patch and RFC 6902 JSON Patch
+// documents to JSON values decoded with encoding/json, i.e. trees of
+// map[string]interface{}, []interface{}, string, float64, bool and nil.
+// Validating the patched document is left to the caller.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Media types of the supported patch formats, sent as the Content-Type of a PATCH request
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Errors reported while parsing or applying a patch, wrapped with the details
+var (
+	// ErrInvalid means the patch document itself is malformed
+	ErrInvalid = errors.New("invalid patch")
+	// ErrPathNotFound means an operation refers to a location missing from the document
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed means a JSON Patch test operation did not match the document
+	ErrTestFailed = errors.New("test failed")
+)
+
+// Patch is a parsed patch document
+type Patch interface {
+	// Apply returns the patched document. doc may be modified in place, so
+	// it must not be used afterwards.
+	Apply(doc interface{}) (interface{}, error)
+}
+
+// MergePatch is an RFC 7396 JSON Merge Patch: objects are merged member by
+// member, null removes a member and any other value replaces the target
+type MergePatch struct {
+	value interface{}
+}
+
+// ParseMerge parses an RFC 7396 merge patch document
+func ParseMerge(body []byte) (MergePatch, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return MergePatch{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return MergePatch{value: value}, nil
+}
+
+// Apply merges the patch into doc
+func (p MergePatch) Apply(doc interface{}) (interface{}, error) {
+	return merge(doc, p.value), nil
+}
+
+// Helper function implementing the MergePatch algorithm of RFC 7396
+func merge(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = merge(object[name], value)
+	}
+	return object
+}
+
+// rawOperation is one operation of a JSON Patch as sent by the client
+type rawOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch is an RFC 6902 JSON Patch, a list of operations applied in order.
+// Applying stops at the first operation that fails.
+type JSONPatch []operation
+
+// operation is a checked rawOperation with its pointers split into tokens
+type operation struct {
+	index int
+	op    string
+	path  []string
+	from  []string
+	value interface{}
+}
+
+// ParseJSON parses an RFC 6902 JSON Patch document, checking that every
+// operation has the members its kind requires
+func ParseJSON(body []byte) (JSONPatch, error) {
+	var ops []rawOperation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		// Report type errors in JSON terms rather than Go types
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			index, member, _ := strings.Cut(typeErr.Field, ".")
+			return nil, fmt.Errorf("%w: operation %s: %s must not be a %s", ErrInvalid, index, member, typeErr.Value)
+		}
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations", ErrInvalid)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	parsed := make(JSONPatch, 0, len(ops))
+	for i, op := range ops {
+		invalid := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: operation %d: %s", ErrInvalid, i, fmt.Sprintf(format, args...))
+		}
+
+		if op.Path == nil {
+			return nil, invalid("missing path")
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, invalid("path: %v", err)
+		}
+		next := operation{index: i, op: op.Op, path: path}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if len(op.Value) == 0 {
+				return nil, invalid("%s requires a value", op.Op)
+			}
+			if err := json.Unmarshal(op.Value, &next.value); err != nil {
+				return nil, invalid("value: %v", err)
+			}
+		case "remove":
+			if len(path) == 0 {
+				return nil, invalid("cannot remove the whole document")
+			}
+		case "move", "copy":
+			if op.From == nil {
+				return nil, invalid("%s requires from", op.Op)
+			}
+			if next.from, err = parsePointer(*op.From); err != nil {
+				return nil, invalid("from: %v", err)
+			}
+			if op.Op == "move" && (len(next.from) == 0 || isProperPrefix(next.from, next.path)) {
+				return nil, invalid("cannot move a value into one of its children")
+			}
+		default:
+			return nil, invalid("unknown op %q", op.Op)
+		}
+		parsed = append(parsed, next)
+	}
+	return parsed, nil
+}
+
+// Apply runs the operations against doc in order
+func (p JSONPatch) Apply(doc interface{}) (interface{}, error) {
+	for _, op := range p {
+		var err error
+		doc, err = op.apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", op.index, op.op, formatPointer(op.path), err)
+		}
+	}
+	return doc, nil
+}
+
+// Helper function to apply one operation
+func (op operation) apply(doc interface{}) (interface{}, error) {
+	switch op.op {
+	case "add":
+		return add(doc, op.path, op.value)
+	case "remove":
+		doc, _, err := remove(doc, op.path)
+		return doc, err
+	case "replace":
+		if len(op.path) == 0 {
+			return op.value, nil
+		}
+		doc, _, err := remove(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, op.value)
+	case "move":
+		doc, value, err := remove(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, value)
+	case "copy":
+		value, err := get(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, deepCopy(value))
+	case "test":
+		value, err := get(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, op.value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return nil, ErrInvalid
+}
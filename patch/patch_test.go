@@ -0,0 +1,332 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Helper function to decode a JSON literal used by a test case
+func decode(t *testing.T, text string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		t.Fatalf("decode %s: %v", text, err)
+	}
+	return value
+}
+
+// Helper function to compare a patched document with the expected JSON
+func assertDocument(t *testing.T, got interface{}, want string) {
+	t.Helper()
+	if expected := decode(t, want); !reflect.DeepEqual(got, expected) {
+		encoded, _ := json.Marshal(got)
+		t.Errorf("got %s, want %s", encoded, want)
+	}
+}
+
+// The examples of RFC 6902 Appendix A
+func TestJSONPatchRFC6902Examples(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{"A.1 add an object member", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`,
+			`{"baz":"qux","foo":"bar"}`, nil},
+		{"A.2 add an array element", `{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`, nil},
+		{"A.3 remove an object member", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`,
+			`{"foo":"bar"}`, nil},
+		{"A.4 remove an array element", `{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`,
+			`{"foo":["bar","baz"]}`, nil},
+		{"A.5 replace a value", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`, nil},
+		{"A.6 move a value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"A.7 move an array element", `{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`, nil},
+		{"A.8 test a value: success", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"A.9 test a value: error", `{"baz":"qux"}`,
+			`[{"op":"test","path":"/baz","value":"bar"}]`,
+			``, ErrTestFailed},
+		{"A.10 add a nested member object", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{"A.11 ignore unrecognized elements", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			`{"foo":"bar","baz":"qux"}`, nil},
+		{"A.12 add to a nonexistent target", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			``, ErrPathNotFound},
+		{"A.14 ~ escape ordering", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":10}]`,
+			`{"/":9,"~1":10}`, nil},
+		{"A.15 comparing strings and numbers", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":"10"}]`,
+			``, ErrTestFailed},
+		{"A.16 add an array value", `{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseJSON([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := p.Apply(decode(t, tt.doc))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			assertDocument(t, got, tt.want)
+		})
+	}
+}
+
+// A.13: a document with a repeated op member is not the add it starts as.
+// encoding/json keeps the last member, which makes it a remove of a missing
+// member, so it still fails.
+func TestJSONPatchRFC6902InvalidDocument(t *testing.T) {
+	p, err := ParseJSON([]byte(`[{"op":"add","path":"/baz","value":"qux","op":"remove"}]`))
+	if err == nil {
+		_, err = p.Apply(decode(t, `{"foo":"bar"}`))
+	}
+	if err == nil {
+		t.Fatal("A.13 was applied, want an error")
+	}
+}
+
+func TestJSONPatchOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{"add replaces an existing member", `{"a":1}`,
+			`[{"op":"add","path":"/a","value":2}]`, `{"a":2}`, nil},
+		{"add at the array end by index", `{"a":[1,2]}`,
+			`[{"op":"add","path":"/a/2","value":3}]`, `{"a":[1,2,3]}`, nil},
+		{"add past the array end", `{"a":[1,2]}`,
+			`[{"op":"add","path":"/a/3","value":3}]`, ``, ErrPathNotFound},
+		{"add with a leading zero index", `{"a":[1,2]}`,
+			`[{"op":"add","path":"/a/01","value":3}]`, ``, ErrPathNotFound},
+		{"add with a negative index", `{"a":[1,2]}`,
+			`[{"op":"add","path":"/a/-1","value":3}]`, ``, ErrPathNotFound},
+		{"add replaces the whole document", `{"a":1}`,
+			`[{"op":"add","path":"","value":[1]}]`, `[1]`, nil},
+		{"add into a scalar", `{"a":1}`,
+			`[{"op":"add","path":"/a/b","value":2}]`, ``, ErrPathNotFound},
+		{"remove a missing member", `{"a":1}`,
+			`[{"op":"remove","path":"/b"}]`, ``, ErrPathNotFound},
+		{"remove past the array end", `{"a":[1]}`,
+			`[{"op":"remove","path":"/a/1"}]`, ``, ErrPathNotFound},
+		{"remove the array end marker", `{"a":[1]}`,
+			`[{"op":"remove","path":"/a/-"}]`, ``, ErrPathNotFound},
+		{"remove the last array element", `{"a":[1,2]}`,
+			`[{"op":"remove","path":"/a/1"}]`, `{"a":[1]}`, nil},
+		{"replace a missing member", `{"a":1}`,
+			`[{"op":"replace","path":"/b","value":2}]`, ``, ErrPathNotFound},
+		{"replace the last array element", `{"a":[1,2]}`,
+			`[{"op":"replace","path":"/a/1","value":3}]`, `{"a":[1,3]}`, nil},
+		{"replace the whole document", `{"a":1}`,
+			`[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`, nil},
+		{"replace with null", `{"a":1}`,
+			`[{"op":"replace","path":"/a","value":null}]`, `{"a":null}`, nil},
+		{"move from a missing member", `{"a":1}`,
+			`[{"op":"move","from":"/b","path":"/c"}]`, ``, ErrPathNotFound},
+		{"move to the same place", `{"a":1}`,
+			`[{"op":"move","from":"/a","path":"/a"}]`, `{"a":1}`, nil},
+		{"copy a member", `{"a":{"b":1}}`,
+			`[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`, nil},
+		{"copy is independent of the original", `{"a":{"b":1}}`,
+			`[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			`{"a":{"b":1},"c":{"b":2}}`, nil},
+		{"copy to the array end", `{"a":[1],"b":2}`,
+			`[{"op":"copy","from":"/b","path":"/a/-"}]`, `{"a":[1,2],"b":2}`, nil},
+		{"copy from a missing member", `{"a":1}`,
+			`[{"op":"copy","from":"/b","path":"/c"}]`, ``, ErrPathNotFound},
+		{"test a missing member", `{"a":1}`,
+			`[{"op":"test","path":"/b","value":1}]`, ``, ErrPathNotFound},
+		{"test an object", `{"a":{"b":[1,"x",null]}}`,
+			`[{"op":"test","path":"/a","value":{"b":[1,"x",null]}}]`, `{"a":{"b":[1,"x",null]}}`, nil},
+		{"test null", `{"a":null}`,
+			`[{"op":"test","path":"/a","value":null}]`, `{"a":null}`, nil},
+		{"test the whole document", `{"a":1}`,
+			`[{"op":"test","path":"","value":{"a":2}}]`, ``, ErrTestFailed},
+		{"an empty member name", `{"":1}`,
+			`[{"op":"replace","path":"/","value":2}]`, `{"":2}`, nil},
+		{"escaped member names", `{"a/b":1,"m~n":2}`,
+			`[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/m~0n","value":3}]`, `{"m~n":3}`, nil},
+		{"operations stop at the first failure", `{"a":1}`,
+			`[{"op":"test","path":"/a","value":2},{"op":"remove","path":"/b"}]`, ``, ErrTestFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseJSON([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := p.Apply(decode(t, tt.doc))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			assertDocument(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseJSONRejectsInvalidPatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"not JSON", `[{`},
+		{"not an array", `{"op":"add","path":"/a","value":1}`},
+		{"path of the wrong type", `[{"op":"add","path":1,"value":1}]`},
+		{"unknown op", `[{"op":"increment","path":"/a","value":1}]`},
+		{"missing path", `[{"op":"add","value":1}]`},
+		{"path without a leading slash", `[{"op":"add","path":"a","value":1}]`},
+		{"add without a value", `[{"op":"add","path":"/a"}]`},
+		{"replace without a value", `[{"op":"replace","path":"/a"}]`},
+		{"test without a value", `[{"op":"test","path":"/a"}]`},
+		{"remove the whole document", `[{"op":"remove","path":""}]`},
+		{"move without from", `[{"op":"move","path":"/a"}]`},
+		{"copy without from", `[{"op":"copy","path":"/a"}]`},
+		{"copy from without a leading slash", `[{"op":"copy","from":"a","path":"/b"}]`},
+		{"move into a child", `[{"op":"move","from":"/a","path":"/a/b"}]`},
+		{"move the whole document", `[{"op":"move","from":"","path":"/a"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseJSON([]byte(tt.patch)); !errors.Is(err, ErrInvalid) {
+				t.Errorf("got %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
+
+// The examples of RFC 7396 Appendix A
+func TestMergePatchRFC7396Examples(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.doc+" + "+tt.patch, func(t *testing.T) {
+			p, err := ParseMerge([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := p.Apply(decode(t, tt.doc))
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			assertDocument(t, got, tt.want)
+		})
+	}
+}
+
+func TestMergePatchDeletesMissingMembersQuietly(t *testing.T) {
+	p, err := ParseMerge([]byte(`{"missing":null,"a":{"gone":null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := p.Apply(decode(t, `{"a":{"kept":1}}`))
+	assertDocument(t, got, `{"a":{"kept":1}}`)
+}
+
+func TestParseMergeRejectsInvalidJSON(t *testing.T) {
+	if _, err := ParseMerge([]byte(`{"a":`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestPointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		tokens  []string
+	}{
+		{"", []string{}},
+		{"/", []string{""}},
+		{"/foo", []string{"foo"}},
+		{"/foo/0", []string{"foo", "0"}},
+		{"/a~1b", []string{"a/b"}},
+		{"/m~0n", []string{"m~n"}},
+		{"/~01", []string{"~1"}},
+		{"/~10", []string{"/0"}},
+		{"/ ", []string{" "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			tokens, err := parsePointer(tt.pointer)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(tokens, tt.tokens) {
+				t.Errorf("got %q, want %q", tokens, tt.tokens)
+			}
+			if got := formatPointer(tokens); got != tt.pointer {
+				t.Errorf("formatted as %q, want %q", got, tt.pointer)
+			}
+		})
+	}
+
+	if _, err := parsePointer("foo"); err == nil {
+		t.Error("a pointer without a leading slash was accepted")
+	}
+}
+
+func TestErrorNamesTheOperation(t *testing.T) {
+	p, err := ParseJSON([]byte(`[{"op":"test","path":"/a","value":1},{"op":"remove","path":"/a~1b"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Apply(decode(t, `{"a":1}`))
+	if want := "operation 1 (remove /a~1b): path not found"; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
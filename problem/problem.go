@@ -0,0 +1,139 @@
+// Package problem defines the RFC 7807 problem details document returned for
+// every API error. Handlers return a *Problem as their error and the central
+// Fiber error handler writes it, so the HTTP status of an error is known to
+// every middleware through Status.
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ContentType is the media type of problem details responses
+const ContentType = "application/problem+json"
+
+// Stable machine-readable error codes, returned in the "code" member
+const (
+	CodeValidation       = "VALIDATION_ERROR"
+	CodeInvalidBody      = "INVALID_BODY"
+	CodeNotFound         = "NOT_FOUND"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeConflict         = "CONFLICT"
+	CodePreconditionFail = "PRECONDITION_FAILED"
+	CodePreconditionReq  = "PRECONDITION_REQUIRED"
+	CodeInternal         = "INTERNAL_ERROR"
+)
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
+}
+
+// Problem is an RFC 7807 problem details document. Besides the standard
+// members it carries a stable error code, per-field errors and the IDs
+// needed to find the request in logs and traces.
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Bad Request"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"Request validation failed"`
+	Instance  string       `json:"instance,omitempty" example:"/users"`
+	Code      string       `json:"code" example:"VALIDATION_ERROR"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Rule      string       `json:"rule,omitempty"`
+	RequestID string       `json:"requestId,omitempty" example:"9b1f0c1e-5d0a-4c55-9a57-1f3c2b8e7d41"`
+	TraceID   string       `json:"traceId,omitempty" example:"4308a32173e6f16b3410f78ef51ec6df"`
+
+	// cause is logged by the server but never sent to the client
+	cause error
+}
+
+// New creates a problem with the given status, code and detail message
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// Validation creates a 400 problem listing the invalid fields
+func Validation(detail string, fields ...FieldError) *Problem {
+	p := New(fiber.StatusBadRequest, CodeValidation, detail)
+	p.Errors = fields
+	return p
+}
+
+// Internal creates a 500 problem. Only detail reaches the client, cause is
+// kept for the server logs.
+func Internal(detail string, cause error) *Problem {
+	return New(fiber.StatusInternalServerError, CodeInternal, detail).WithCause(cause)
+}
+
+// WithCause attaches the underlying error, which is logged but not sent
+func (p *Problem) WithCause(err error) *Problem {
+	p.cause = err
+	return p
+}
+
+// Error returns the detail, followed by the cause when there is one
+func (p *Problem) Error() string {
+	if p.cause != nil {
+		return p.Detail + ": " + p.cause.Error()
+	}
+	return p.Detail
+}
+
+func (p *Problem) Unwrap() error {
+	return p.cause
+}
+
+// From converts any error returned by a handler into a problem. Fiber errors
+// (unknown route, wrong method, oversized body) keep their status; any other
+// error becomes an internal error whose text is not exposed.
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return New(fiberErr.Code, codeForStatus(fiberErr.Code), fiberErr.Message)
+	}
+	return Internal("Internal server error", err)
+}
+
+// Status returns the HTTP status the error handler will send for err
+func Status(err error) int {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p.Status
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// Helper function to pick a code for errors raised by Fiber itself
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case fiber.StatusBadRequest, fiber.StatusRequestEntityTooLarge, fiber.StatusUnprocessableEntity, fiber.StatusUnsupportedMediaType:
+		return CodeInvalidBody
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return "HTTP_" + strconv.Itoa(status)
+}
@@ -0,0 +1,76 @@
+// Package ratelimit limits requests per client with token buckets. Each route
+// group has its own limit, and a client is identified by its partner API key,
+// its authenticated user or its IP address, in that order.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"temp-kbtg-backend/config"
+	"time"
+)
+
+// Bucket is the stored state of one client's token bucket
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result is the state of a bucket after a request tried to take a token
+type Result struct {
+	Allowed    bool
+	Limit      int           // Bucket capacity (burst)
+	Remaining  int           // Whole tokens left
+	RetryAfter time.Duration // Until the next token, when the request was not allowed
+	Reset      time.Duration // Until the bucket is full again
+}
+
+// Store keeps the buckets. Take must be atomic per key.
+type Store interface {
+	// Take refills the bucket named key for the time since its last use and takes one token if there is one
+	Take(ctx context.Context, key string, limit config.Limit, now time.Time) (Result, error)
+	// Purge deletes buckets untouched since before and returns how many were removed
+	Purge(ctx context.Context, before time.Time) (int, error)
+	Close() error
+}
+
+// Helper function to compute the refill rate in tokens per second
+func rate(limit config.Limit) float64 {
+	return float64(limit.Requests) / limit.Period.Seconds()
+}
+
+// FillTime is how long an empty bucket takes to refill completely. A bucket
+// untouched for longer is full and can be forgotten.
+func FillTime(limit config.Limit) time.Duration {
+	return time.Duration(float64(limit.Burst) / rate(limit) * float64(time.Second))
+}
+
+// take applies one request to a bucket. found is false for a client without a
+// bucket yet, which starts full.
+func take(bucket Bucket, found bool, limit config.Limit, now time.Time) (Bucket, Result) {
+	capacity := float64(limit.Burst)
+	tokens := capacity
+	if found {
+		elapsed := now.Sub(bucket.UpdatedAt).Seconds()
+		if elapsed < 0 {
+			elapsed = 0 // another process wrote a slightly later clock
+		}
+		tokens = math.Min(capacity, bucket.Tokens+elapsed*rate(limit))
+	}
+
+	result := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate(limit))
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / rate(limit))
+	return Bucket{Tokens: tokens, UpdatedAt: now}, result
+}
+
+// Helper function to convert seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"temp-kbtg-backend/config"
+	"testing"
+	"time"
+)
+
+// testLimit refills one token per second into a bucket of three
+var testLimit = config.Limit{Requests: 60, Period: time.Minute, Burst: 3}
+
+func TestTakeAllowsBurstThenRefills(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Unix(1_800_000_000, 0)
+
+	steps := []struct {
+		name       string
+		at         time.Duration // Since start
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"first request of the burst", 0, true, 2, 0, time.Second},
+		{"second request of the burst", 0, true, 1, 0, 2 * time.Second},
+		{"last request of the burst", 0, true, 0, 0, 3 * time.Second},
+		{"burst used up", 0, false, 0, time.Second, 3 * time.Second},
+		{"half a token refilled", 500 * time.Millisecond, false, 0, 500 * time.Millisecond, 2500 * time.Millisecond},
+		{"one token refilled", time.Second, true, 0, 0, 3 * time.Second},
+		{"clock went backwards", 0, false, 0, time.Second, 3 * time.Second},
+		{"refill stops at the burst", time.Minute, true, 2, 0, time.Second},
+	}
+	for _, step := range steps {
+		result, err := store.Take(context.Background(), "client", testLimit, start.Add(step.at))
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		want := Result{Allowed: step.allowed, Limit: 3, Remaining: step.remaining, RetryAfter: step.retryAfter, Reset: step.reset}
+		if result != want {
+			t.Errorf("%s: got %+v, want %+v", step.name, result, want)
+		}
+	}
+}
+
+func TestTakeKeepsClientsApart(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(1_800_000_000, 0)
+
+	for i := 0; i < testLimit.Burst; i++ {
+		store.Take(context.Background(), "a", testLimit, now)
+	}
+	if result, _ := store.Take(context.Background(), "a", testLimit, now); result.Allowed {
+		t.Error("client a was allowed past its burst")
+	}
+	if result, _ := store.Take(context.Background(), "b", testLimit, now); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("client b got %+v, want a full bucket", result)
+	}
+}
+
+func TestFillTime(t *testing.T) {
+	if got := FillTime(testLimit); got != 3*time.Second {
+		t.Errorf("FillTime is %s, want 3s", got)
+	}
+	if got := FillTime(config.Limit{Requests: 10, Period: time.Hour, Burst: 5}); got != 30*time.Minute {
+		t.Errorf("FillTime is %s, want 30m", got)
+	}
+}
+
+func TestMemoryStorePurgesIdleBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(1_800_000_000, 0)
+	store.Take(context.Background(), "old", testLimit, now)
+	store.Take(context.Background(), "new", testLimit, now.Add(time.Minute))
+
+	purged, err := store.Purge(context.Background(), now.Add(time.Second))
+	if err != nil || purged != 1 {
+		t.Fatalf("Purge returned %d, %v, want 1", purged, err)
+	}
+	if _, ok := store.buckets["new"]; !ok || len(store.buckets) != 1 {
+		t.Errorf("buckets left are %v", store.buckets)
+	}
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/config"
+	"temp-kbtg-backend/metrics"
+	"temp-kbtg-backend/problem"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeRateLimited is the problem code of a request rejected with 429
+const CodeRateLimited = "RATE_LIMITED"
+
+// Limiter hands out one middleware per route group, all backed by one store
+type Limiter struct {
+	store Store            // nil when rate limiting is disabled
+	now   func() time.Time // Clock the buckets are refilled by
+
+	mu      sync.Mutex
+	maxFill time.Duration // Longest FillTime of the groups, see Purge
+}
+
+// Open creates the configured store. A disabled limiter lets every request through.
+func Open(cfg config.RateLimit) (*Limiter, error) {
+	if !cfg.Enabled {
+		return &Limiter{now: time.Now}, nil
+	}
+	if cfg.Store == "sqlite" {
+		store, err := OpenSQLiteStore(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return &Limiter{store: store, now: time.Now}, nil
+	}
+	return &Limiter{store: NewMemoryStore(), now: time.Now}, nil
+}
+
+// Middleware limits each client to limit within the named group. It must run
+// after the middleware that authenticates the caller, so the client is known.
+// Store errors are logged and the request is let through.
+func (l *Limiter) Middleware(group string, limit config.Limit) fiber.Handler {
+	return l.middleware(group, limit, clientKey, nil)
+}
+
+// IPMiddleware limits each IP address to limit within the named group,
+// whoever the caller claims to be. It runs before authentication so that
+// requests with invalid credentials are counted too. Requests for which skip
+// returns true are not limited.
+func (l *Limiter) IPMiddleware(group string, limit config.Limit, skip func(*fiber.Ctx) bool) fiber.Handler {
+	return l.middleware(group, limit, func(c *fiber.Ctx) string { return "ip:" + c.IP() }, skip)
+}
+
+// Helper function to build a middleware that takes from the bucket of the client named by key
+func (l *Limiter) middleware(group string, limit config.Limit, key func(*fiber.Ctx) string, skip func(*fiber.Ctx) bool) fiber.Handler {
+	if l.store == nil {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+
+	l.mu.Lock()
+	l.maxFill = max(l.maxFill, FillTime(limit))
+	l.mu.Unlock()
+
+	policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(int(math.Ceil(limit.Period.Seconds()))) + ";burst=" + strconv.Itoa(limit.Burst)
+	return func(c *fiber.Ctx) error {
+		if skip != nil && skip(c) {
+			return c.Next()
+		}
+		ctx := c.UserContext()
+		result, err := l.store.Take(ctx, group+":"+key(c), limit, l.now())
+		if err != nil {
+			slog.WarnContext(ctx, "Rate limit store failed, allowing request", "group", group, "error", err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Policy", policy)
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if result.Allowed {
+			return c.Next()
+		}
+
+		metrics.ObserveRateLimited(group)
+		retryAfter := ceilSeconds(result.RetryAfter)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return problem.New(fiber.StatusTooManyRequests, CodeRateLimited,
+			"Rate limit exceeded, retry in "+strconv.Itoa(retryAfter)+"s")
+	}
+}
+
+// Purge forgets buckets idle long enough to have refilled completely
+func (l *Limiter) Purge(ctx context.Context) (int, error) {
+	if l.store == nil {
+		return 0, nil
+	}
+	l.mu.Lock()
+	idle := l.maxFill
+	l.mu.Unlock()
+	return l.store.Purge(ctx, l.now().Add(-idle))
+}
+
+// Close closes the store
+func (l *Limiter) Close() error {
+	if l.store == nil {
+		return nil
+	}
+	return l.store.Close()
+}
+
+// Helper function to identify the client: the partner API key, the
+// authenticated user, or the IP address for anonymous requests
+func clientKey(c *fiber.Ctx) string {
+	ctx := c.UserContext()
+	if key, ok := auth.APIKeyFromContext(ctx); ok {
+		return "key:" + key.KeyID
+	}
+	if principal, ok := auth.FromContext(ctx); ok {
+		if principal.Role == auth.RoleMember {
+			return "user:" + strconv.Itoa(principal.UserID)
+		}
+		return "staff:" + principal.Subject
+	}
+	return "ip:" + c.IP()
+}
+
+// Helper function to round a duration up to whole seconds for the headers
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,189 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"temp-kbtg-backend/auth"
+	"temp-kbtg-backend/config"
+	"temp-kbtg-backend/handlers"
+	"temp-kbtg-backend/models"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper function to create a limiter on a memory store whose clock stands still at now
+func newTestLimiter(now *time.Time) *Limiter {
+	return &Limiter{store: NewMemoryStore(), now: func() time.Time { return *now }}
+}
+
+// Helper function to serve GET / through the given middleware. The X-Test-Key,
+// X-Test-User and X-Test-Staff headers stand in for the authentication middleware.
+func newTestApp(middleware ...fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: handlers.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		if keyID := c.Get("X-Test-Key"); keyID != "" {
+			ctx = auth.WithAPIKey(ctx, models.APIKey{KeyID: keyID})
+		}
+		if userID := c.Get("X-Test-User"); userID != "" {
+			id, _ := strconv.Atoi(userID)
+			ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: userID, Role: auth.RoleMember, UserID: id})
+		}
+		if name := c.Get("X-Test-Staff"); name != "" {
+			ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: name, Role: auth.RoleSupport})
+		}
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	for _, m := range middleware {
+		app.Use(m)
+	}
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(clientKey(c)) })
+	return app
+}
+
+// Helper function to send GET / with the given headers
+func get(t *testing.T, app *fiber.App, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestMiddlewareSetsHeadersAndRejects(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	limiter := newTestLimiter(&now)
+	app := newTestApp(limiter.Middleware("read", config.Limit{Requests: 60, Period: time.Minute, Burst: 2}))
+
+	for i, remaining := range []string{"1", "0"} {
+		resp, _ := get(t, app, nil)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request %d got %d", i+1, resp.StatusCode)
+		}
+		headers := map[string]string{
+			"RateLimit-Policy":    "60;w=60;burst=2",
+			"RateLimit-Limit":     "2",
+			"RateLimit-Remaining": remaining,
+			"RateLimit-Reset":     strconv.Itoa(i + 1),
+		}
+		for name, want := range headers {
+			if got := resp.Header.Get(name); got != want {
+				t.Errorf("request %d: %s is %q, want %q", i+1, name, got, want)
+			}
+		}
+		if resp.Header.Get(fiber.HeaderRetryAfter) != "" {
+			t.Errorf("request %d has Retry-After", i+1)
+		}
+	}
+
+	resp, body := get(t, app, nil)
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("request over the burst got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderRetryAfter); got != "1" {
+		t.Errorf("Retry-After is %q, want 1", got)
+	}
+	if got := resp.Header.Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining is %q, want 0", got)
+	}
+	if got := resp.Header.Get(fiber.HeaderContentType); got != "application/problem+json" {
+		t.Errorf("Content-Type is %q", got)
+	}
+	var problem struct {
+		Status int    `json:"status"`
+		Code   string `json:"code"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(body), &problem); err != nil {
+		t.Fatalf("body is not JSON: %s", body)
+	}
+	if problem.Status != fiber.StatusTooManyRequests || problem.Code != CodeRateLimited || problem.Detail != "Rate limit exceeded, retry in 1s" {
+		t.Errorf("problem is %+v", problem)
+	}
+
+	// A second later the next token is there
+	now = now.Add(time.Second)
+	if resp, _ := get(t, app, nil); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("request after the refill got %d", resp.StatusCode)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	app := newTestApp()
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"anonymous", nil, "ip:0.0.0.0"},
+		{"member", map[string]string{"X-Test-User": "7"}, "user:7"},
+		{"staff", map[string]string{"X-Test-Staff": "alice"}, "staff:alice"},
+		{"API key before principal", map[string]string{"X-Test-Key": "pk_1", "X-Test-User": "7"}, "key:pk_1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := get(t, app, tt.headers); got != tt.want {
+				t.Errorf("clientKey is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareLimitsEachClient(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	limiter := newTestLimiter(&now)
+	app := newTestApp(limiter.Middleware("read", config.Limit{Requests: 60, Period: time.Minute, Burst: 1}))
+
+	for _, headers := range []map[string]string{nil, {"X-Test-User": "7"}, {"X-Test-User": "8"}, {"X-Test-Key": "pk_1"}} {
+		if resp, _ := get(t, app, headers); resp.StatusCode != fiber.StatusOK {
+			t.Errorf("first request of %v got %d", headers, resp.StatusCode)
+		}
+	}
+	if resp, _ := get(t, app, map[string]string{"X-Test-User": "7"}); resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("second request of user 7 got %d", resp.StatusCode)
+	}
+}
+
+func TestIPMiddlewareIgnoresCallerAndSkips(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	limiter := newTestLimiter(&now)
+	skip := func(c *fiber.Ctx) bool { return c.Get("X-Probe") != "" }
+	app := newTestApp(limiter.IPMiddleware("ip", config.Limit{Requests: 60, Period: time.Minute, Burst: 1}, skip))
+
+	if resp, _ := get(t, app, map[string]string{"X-Test-User": "7"}); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("first request got %d", resp.StatusCode)
+	}
+	// Another caller from the same address shares the bucket
+	if resp, _ := get(t, app, map[string]string{"X-Test-Key": "pk_1"}); resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("second request from the address got %d", resp.StatusCode)
+	}
+	if resp, _ := get(t, app, map[string]string{"X-Probe": "1"}); resp.StatusCode != fiber.StatusOK || resp.Header.Get("RateLimit-Limit") != "" {
+		t.Errorf("skipped request got %d with RateLimit-Limit %q", resp.StatusCode, resp.Header.Get("RateLimit-Limit"))
+	}
+}
+
+func TestDisabledLimiterAllowsEverything(t *testing.T) {
+	limiter, err := Open(config.RateLimit{Enabled: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(limiter.Middleware("read", config.Limit{Requests: 1, Period: time.Hour, Burst: 1}))
+	for i := 0; i < 3; i++ {
+		if resp, _ := get(t, app, nil); resp.StatusCode != fiber.StatusOK || resp.Header.Get("RateLimit-Limit") != "" {
+			t.Fatalf("request %d got %d with RateLimit-Limit %q", i+1, resp.StatusCode, resp.Header.Get("RateLimit-Limit"))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"temp-kbtg-backend/config"
+	"time"
+)
+
+// MemoryStore keeps buckets in process memory. Limits start over on restart
+// and are not shared between instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]Bucket
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]Bucket{}}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit config.Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, found := s.buckets[key]
+	bucket, result := take(bucket, found, limit, now)
+	s.buckets[key] = bucket
+	return result, nil
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for key, bucket := range s.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(s.buckets, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
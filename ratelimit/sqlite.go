@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"temp-kbtg-backend/config"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/tracing"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// bucketSchema is created on open. The buckets live in their own file so
+// counting requests never waits for the write lock of users.db.
+const bucketSchema = `
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+	key TEXT PRIMARY KEY,
+	tokens REAL NOT NULL,
+	updated_at INTEGER NOT NULL -- Unix nanoseconds
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated ON rate_limit_buckets(updated_at);
+`
+
+// SQLiteStore keeps buckets in a SQLite file, so limits survive restarts and
+// are shared by every process on the host that opens the same file
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens or creates the bucket file at path
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := tracing.OpenDB("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate", semconv.DBSystemSqlite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rate limit store: %v", err)
+	}
+	if _, err := db.Exec(bucketSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create rate limit store: %v", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Take(ctx context.Context, key string, limit config.Limit, now time.Time) (Result, error) {
+	tx, err := database.Begin(ctx, s.db)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	var bucket Bucket
+	var updatedAt int64
+	found := true
+	err = tx.QueryRowContext(ctx, "SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = ?", key).Scan(&bucket.Tokens, &updatedAt)
+	if err == sql.ErrNoRows {
+		found = false
+	} else if err != nil {
+		return Result{}, err
+	}
+	bucket.UpdatedAt = time.Unix(0, updatedAt)
+
+	bucket, result := take(bucket, found, limit, now)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET tokens = excluded.tokens, updated_at = excluded.updated_at
+	`, key, bucket.Tokens, bucket.UpdatedAt.UnixNano())
+	if err != nil {
+		return Result{}, err
+	}
+	return result, tx.Commit()
+}
+
+func (s *SQLiteStore) Purge(ctx context.Context, before time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < ?", before.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"path/filepath"
+	"temp-kbtg-backend/config"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSQLiteStoreRefillsAndPurges(t *testing.T) {
+	store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "ratelimit.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	start := time.Unix(1_800_000_000, 0)
+
+	for i := 0; i < testLimit.Burst; i++ {
+		if result, err := store.Take(ctx, "client", testLimit, start); err != nil || !result.Allowed {
+			t.Fatalf("request %d got %+v, %v", i+1, result, err)
+		}
+	}
+	if result, _ := store.Take(ctx, "client", testLimit, start); result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("request over the burst got %+v", result)
+	}
+	if result, _ := store.Take(ctx, "client", testLimit, start.Add(time.Second)); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("request after the refill got %+v", result)
+	}
+
+	store.Take(ctx, "other", testLimit, start.Add(time.Minute))
+	if purged, err := store.Purge(ctx, start.Add(30*time.Second)); err != nil || purged != 1 {
+		t.Errorf("Purge returned %d, %v, want 1", purged, err)
+	}
+}
+
+func TestLimitersShareSQLiteBuckets(t *testing.T) {
+	cfg := config.RateLimit{Enabled: true, Store: "sqlite", SQLitePath: filepath.Join(t.TempDir(), "ratelimit.db")}
+	limit := config.Limit{Requests: 60, Period: time.Minute, Burst: 2}
+	now := time.Unix(1_800_000_000, 0)
+
+	// Two limiters on one file stand for two processes on the host
+	var apps []*fiber.App
+	for i := 0; i < 2; i++ {
+		limiter, err := Open(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer limiter.Close()
+		limiter.now = func() time.Time { return now }
+		apps = append(apps, newTestApp(limiter.Middleware("read", limit)))
+	}
+
+	for i, app := range apps {
+		if resp, _ := get(t, app, nil); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request %d got %d", i+1, resp.StatusCode)
+		}
+	}
+	for i, app := range apps {
+		resp, _ := get(t, app, nil)
+		if resp.StatusCode != fiber.StatusTooManyRequests || resp.Header.Get("RateLimit-Remaining") != "0" {
+			t.Errorf("limiter %d allowed a request over the shared burst: %d", i+1, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+type apiKeyRepository struct {
+	s *Store
+}
+
+func (r apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	defer r.s.lock()()
+	for _, existing := range r.s.st.apiKeys {
+		if existing.KeyID == key.KeyID {
+			return repository.ErrConflict
+		}
+	}
+
+	key.ID = len(r.s.st.apiKeys) + 1
+	key.Scopes = append([]models.APIKeyScope{}, key.Scopes...)
+	r.s.st.apiKeys = append(r.s.st.apiKeys, *key)
+	return nil
+}
+
+func (r apiKeyRepository) GetByID(ctx context.Context, id int) (models.APIKey, error) {
+	defer r.s.lock()()
+	if id < 1 || id > len(r.s.st.apiKeys) {
+		return models.APIKey{}, repository.ErrNotFound
+	}
+	return r.s.st.apiKeys[id-1], nil
+}
+
+func (r apiKeyRepository) GetByKeyID(ctx context.Context, keyID string) (models.APIKey, error) {
+	defer r.s.lock()()
+	for _, key := range r.s.st.apiKeys {
+		if key.KeyID == keyID {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, repository.ErrNotFound
+}
+
+func (r apiKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	defer r.s.lock()()
+	return append([]models.APIKey{}, r.s.st.apiKeys...), nil
+}
+
+func (r apiKeyRepository) Rotate(ctx context.Context, id int, secretSalt string, at time.Time) error {
+	defer r.s.lock()()
+	key, err := r.activeKey(id)
+	if err != nil {
+		return err
+	}
+	key.SecretSalt = secretSalt
+	key.RotatedAt = &at
+	return nil
+}
+
+func (r apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	defer r.s.lock()()
+	key, err := r.activeKey(id)
+	if err != nil {
+		return err
+	}
+	key.RevokedAt = &at
+	return nil
+}
+
+// Helper function to find a key that is not revoked, like the WHERE clause of the SQL stores
+func (r apiKeyRepository) activeKey(id int) (*models.APIKey, error) {
+	if id < 1 || id > len(r.s.st.apiKeys) || r.s.st.apiKeys[id-1].RevokedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+	return &r.s.st.apiKeys[id-1], nil
+}
+
+func (r apiKeyRepository) UseNonce(ctx context.Context, id int, nonce string, at time.Time) error {
+	defer r.s.lock()()
+	if id < 1 || id > len(r.s.st.apiKeys) {
+		return errors.New("nonce api key does not exist")
+	}
+	key := apiKeyNonce{apiKeyID: id, nonce: nonce}
+	if _, ok := r.s.st.nonces[key]; ok {
+		return repository.ErrConflict
+	}
+	r.s.st.nonces[key] = at
+	return nil
+}
+
+func (r apiKeyRepository) PurgeNonces(ctx context.Context, before time.Time) (int, error) {
+	defer r.s.lock()()
+	purged := 0
+	for key, at := range r.s.st.nonces {
+		if at.Before(before) {
+			delete(r.s.st.nonces, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
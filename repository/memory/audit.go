@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+type auditRepository struct {
+	s *Store
+}
+
+func (r auditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	defer r.s.lock()()
+	entry.ID = len(r.s.st.audit) + 1
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Second)
+	r.s.st.audit = append(r.s.st.audit, *entry)
+	return nil
+}
+
+func (r auditRepository) List(ctx context.Context, filter repository.AuditFilter, limit, offset int) ([]models.AuditEntry, int, error) {
+	defer r.s.lock()()
+
+	// Walk backwards for newest first; IDs grow with created_at
+	matched := []models.AuditEntry{}
+	for i := len(r.s.st.audit) - 1; i >= 0; i-- {
+		entry := r.s.st.audit[i]
+		if auditMatches(entry, filter) {
+			matched = append(matched, entry)
+		}
+	}
+
+	total := len(matched)
+	if offset >= total {
+		return []models.AuditEntry{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+// Helper function to apply an audit filter like the WHERE clause of the SQL stores
+func auditMatches(entry models.AuditEntry, filter repository.AuditFilter) bool {
+	switch {
+	case filter.Actor != "" && entry.Actor != filter.Actor,
+		filter.Action != "" && entry.Action != filter.Action,
+		filter.EntityType != "" && entry.EntityType != filter.EntityType,
+		filter.EntityID != 0 && entry.EntityID != filter.EntityID,
+		filter.RequestID != "" && entry.RequestID != filter.RequestID,
+		!filter.From.IsZero() && entry.CreatedAt.Before(filter.From),
+		!filter.To.IsZero() && entry.CreatedAt.After(filter.To):
+		return false
+	}
+	return true
+}
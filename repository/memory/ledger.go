@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+)
+
+type ledgerRepository struct {
+	s *Store
+}
+
+func (r ledgerRepository) Append(ctx context.Context, entries ...models.PointLedger) error {
+	defer r.s.lock()()
+	for _, e := range entries {
+		// Mirror the foreign keys of point_ledger
+		if _, ok := r.s.st.users[e.UserID]; !ok {
+			return errors.New("ledger user does not exist")
+		}
+		if e.TransferID != nil && (*e.TransferID < 1 || *e.TransferID > len(r.s.st.transfers)) {
+			return errors.New("ledger transfer does not exist")
+		}
+		// Mirror the unique index on partner references
+		if isPartnerEvent(e.EventType) && e.Reference != nil {
+			for _, existing := range r.s.st.ledger {
+				if isPartnerEvent(existing.EventType) && existing.Reference != nil && *existing.Reference == *e.Reference {
+					return repository.ErrConflict
+				}
+			}
+		}
+
+		e.ID = len(r.s.st.ledger) + 1
+		r.s.st.ledger = append(r.s.st.ledger, e)
+	}
+	return nil
+}
+
+func (r ledgerRepository) ListByUser(ctx context.Context, userID int) ([]models.PointLedger, error) {
+	defer r.s.lock()()
+	entries := []models.PointLedger{}
+	for _, e := range r.s.st.ledger {
+		if e.UserID == userID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Helper function to match the event types covered by idx_ledger_partner_reference
+func isPartnerEvent(eventType models.EventType) bool {
+	return eventType == models.EventEarn || eventType == models.EventRedeem
+}
@@ -0,0 +1,132 @@
+// Package memory provides an in-memory implementation of the repository
+// interfaces. It is meant for tests and keeps the same rules as the SQLite
+// schema: unique membership IDs, emails, idempotency keys, API key IDs,
+// nonces and partner references, and balances that cannot go negative.
+package memory
+
+import (
+	"context"
+	"sync"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// state holds every table, guarded by a single mutex
+type state struct {
+	mu            sync.Mutex
+	users         map[int]models.User
+	statusHistory []models.UserStatusChange
+	transfers     []models.Transfer
+	reviews       []models.TransferReview
+	ledger        []models.PointLedger
+	apiKeys       []models.APIKey
+	nonces        map[apiKeyNonce]time.Time
+	audit         []models.AuditEntry
+}
+
+// apiKeyNonce is the primary key of api_key_nonces
+type apiKeyNonce struct {
+	apiKeyID int
+	nonce    string
+}
+
+// clone copies the tables so a failed transaction can be rolled back
+func (st *state) clone() *state {
+	users := make(map[int]models.User, len(st.users))
+	for id, user := range st.users {
+		users[id] = user
+	}
+	nonces := make(map[apiKeyNonce]time.Time, len(st.nonces))
+	for key, at := range st.nonces {
+		nonces[key] = at
+	}
+	return &state{
+		users:         users,
+		statusHistory: append([]models.UserStatusChange{}, st.statusHistory...),
+		transfers:     append([]models.Transfer{}, st.transfers...),
+		reviews:       append([]models.TransferReview{}, st.reviews...),
+		ledger:        append([]models.PointLedger{}, st.ledger...),
+		apiKeys:       append([]models.APIKey{}, st.apiKeys...),
+		nonces:        nonces,
+		audit:         append([]models.AuditEntry{}, st.audit...),
+	}
+}
+
+// Store is the in-memory implementation of repository.Store
+type Store struct {
+	st   *state
+	inTx bool // the mutex is already held by WithinTx
+}
+
+// New creates an empty store containing only the system account
+func New() *Store {
+	s := &Store{st: &state{users: map[int]models.User{}, nonces: map[apiKeyNonce]time.Time{}}}
+	s.Users().Create(context.Background(), &models.User{
+		MembershipID:    models.SystemMembershipID,
+		FirstName:       "System",
+		LastName:        "Account",
+		PhoneNumber:     "-",
+		Email:           "system@kbtg.local",
+		MembershipLevel: "System",
+		Status:          models.UserFrozen,
+	})
+	return s
+}
+
+// lock takes the store mutex unless the caller already holds it through WithinTx
+func (s *Store) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.st.mu.Lock()
+	return s.st.mu.Unlock
+}
+
+func (s *Store) Users() repository.UserRepository {
+	return userRepository{s}
+}
+
+func (s *Store) Transfers() repository.TransferRepository {
+	return transferRepository{s}
+}
+
+func (s *Store) Ledger() repository.LedgerRepository {
+	return ledgerRepository{s}
+}
+
+func (s *Store) APIKeys() repository.APIKeyRepository {
+	return apiKeyRepository{s}
+}
+
+func (s *Store) Audit() repository.AuditRepository {
+	return auditRepository{s}
+}
+
+// WithinTx holds the store mutex for the whole of fn and restores the
+// previous state when fn returns an error
+func (s *Store) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.st.mu.Lock()
+	defer s.st.mu.Unlock()
+
+	snapshot := s.st.clone()
+	if err := fn(&Store{st: s.st, inTx: true}); err != nil {
+		s.st.users = snapshot.users
+		s.st.statusHistory = snapshot.statusHistory
+		s.st.transfers = snapshot.transfers
+		s.st.reviews = snapshot.reviews
+		s.st.ledger = snapshot.ledger
+		s.st.apiKeys = snapshot.apiKeys
+		s.st.nonces = snapshot.nonces
+		s.st.audit = snapshot.audit
+		return err
+	}
+	return nil
+}
+
+// Store must satisfy repository.Store
+var _ repository.Store = (*Store)(nil)
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/repository/memory"
+	"temp-kbtg-backend/repository/repositorytest"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		return memory.New()
+	})
+}
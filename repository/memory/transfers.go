@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+type transferRepository struct {
+	s *Store
+}
+
+// isActive reports whether the fraud rules count the transfer
+func isActive(t models.Transfer) bool {
+	return t.Status == models.StatusPending || t.Status == models.StatusProcessing || t.Status == models.StatusCompleted
+}
+
+func (r transferRepository) Create(ctx context.Context, t *models.Transfer) error {
+	defer r.s.lock()()
+	for _, existing := range r.s.st.transfers {
+		if existing.IdemKey == t.IdemKey {
+			return repository.ErrConflict
+		}
+	}
+
+	id := len(r.s.st.transfers) + 1
+	t.TransferID = &id
+	r.s.st.transfers = append(r.s.st.transfers, *t)
+	return nil
+}
+
+func (r transferRepository) GetByIdemKey(ctx context.Context, idemKey string) (models.Transfer, error) {
+	defer r.s.lock()()
+	for _, t := range r.s.st.transfers {
+		if t.IdemKey == idemKey {
+			return t, nil
+		}
+	}
+	return models.Transfer{}, repository.ErrNotFound
+}
+
+func (r transferRepository) ListByUser(ctx context.Context, userID, limit, offset int) ([]models.Transfer, int, error) {
+	defer r.s.lock()()
+	matched := []models.Transfer{}
+	for _, t := range r.s.st.transfers {
+		if t.FromUserID == userID || t.ToUserID == userID {
+			matched = append(matched, t)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	total := len(matched)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+func (r transferRepository) UpdateStatus(ctx context.Context, id int, status models.TransferStatus, at time.Time, failReason *string) error {
+	defer r.s.lock()()
+	if id < 1 || id > len(r.s.st.transfers) {
+		return repository.ErrNotFound
+	}
+
+	t := &r.s.st.transfers[id-1]
+	now := at.UTC().Truncate(time.Second)
+	t.Status = status
+	t.UpdatedAt = now
+	if status == models.StatusCompleted {
+		t.CompletedAt = &now
+	}
+	if failReason != nil {
+		t.FailReason = failReason
+	}
+	return nil
+}
+
+func (r transferRepository) CountSentSince(ctx context.Context, fromUserID int, since time.Time) (int, error) {
+	defer r.s.lock()()
+	count := 0
+	for _, t := range r.s.st.transfers {
+		if t.FromUserID == fromUserID && !t.CreatedAt.Before(since) && isActive(t) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r transferRepository) CountCompletedBetween(ctx context.Context, fromUserID, toUserID int) (int, error) {
+	defer r.s.lock()()
+	count := 0
+	for _, t := range r.s.st.transfers {
+		if t.FromUserID == fromUserID && t.ToUserID == toUserID && t.Status == models.StatusCompleted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r transferRepository) CountSendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (int, error) {
+	defer r.s.lock()()
+	senders := map[int]bool{}
+	for _, t := range r.s.st.transfers {
+		if t.ToUserID == toUserID && t.FromUserID != excludeUserID && !t.CreatedAt.Before(since) && isActive(t) {
+			senders[t.FromUserID] = true
+		}
+	}
+	return len(senders), nil
+}
+
+func (r transferRepository) CreateReview(ctx context.Context, review *models.TransferReview) error {
+	defer r.s.lock()()
+	if review.TransferID < 1 || review.TransferID > len(r.s.st.transfers) {
+		return errors.New("transfer does not exist")
+	}
+
+	review.ID = len(r.s.st.reviews) + 1
+	stored := *review
+	stored.Transfer = nil
+	r.s.st.reviews = append(r.s.st.reviews, stored)
+	return nil
+}
+
+// Helper function to attach the current transfer to a stored review
+func (st *state) withTransfer(review models.TransferReview) models.TransferReview {
+	transfer := st.transfers[review.TransferID-1]
+	review.Transfer = &transfer
+	return review
+}
+
+func (r transferRepository) GetReview(ctx context.Context, id int) (models.TransferReview, error) {
+	defer r.s.lock()()
+	if id < 1 || id > len(r.s.st.reviews) {
+		return models.TransferReview{}, repository.ErrNotFound
+	}
+	return r.s.st.withTransfer(r.s.st.reviews[id-1]), nil
+}
+
+func (r transferRepository) ListReviews(ctx context.Context, status models.ReviewStatus) ([]models.TransferReview, error) {
+	defer r.s.lock()()
+	reviews := []models.TransferReview{}
+	for _, review := range r.s.st.reviews {
+		if review.Status == status {
+			reviews = append(reviews, r.s.st.withTransfer(review))
+		}
+	}
+	return reviews, nil
+}
+
+func (r transferRepository) ResolveReview(ctx context.Context, id int, status models.ReviewStatus, note *string, at time.Time) error {
+	defer r.s.lock()()
+	if id < 1 || id > len(r.s.st.reviews) {
+		return repository.ErrNotFound
+	}
+
+	resolvedAt := at.UTC().Truncate(time.Second)
+	review := &r.s.st.reviews[id-1]
+	review.Status = status
+	review.ResolutionNote = note
+	review.ResolvedAt = &resolvedAt
+	return nil
+}
@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+type userRepository struct {
+	s *Store
+}
+
+func (r userRepository) GetByID(ctx context.Context, id int) (models.User, error) {
+	defer r.s.lock()()
+	user, ok := r.s.st.users[id]
+	if !ok {
+		return models.User{}, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+// GetByIDForUpdate needs no row lock, WithinTx holds the store mutex
+func (r userRepository) GetByIDForUpdate(ctx context.Context, id int) (models.User, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r userRepository) GetByMembershipID(ctx context.Context, membershipID string) (models.User, error) {
+	defer r.s.lock()()
+	for _, user := range r.s.st.users {
+		if user.MembershipID == membershipID {
+			return user, nil
+		}
+	}
+	return models.User{}, repository.ErrNotFound
+}
+
+func (r userRepository) List(ctx context.Context, filter repository.UserFilter, page repository.UserPage) ([]models.User, int, error) {
+	defer r.s.lock()()
+	matched := []models.User{}
+	for _, user := range r.s.st.users {
+		if userMatches(user, filter) {
+			matched = append(matched, user)
+		}
+	}
+	total := len(matched)
+
+	sort.Slice(matched, func(i, j int) bool {
+		return compareUsers(page.Sort, matched[i], matched[j], page.Desc) < 0
+	})
+
+	// Keyset pagination continues after the cursor instead of skipping rows
+	offset := page.Offset
+	if page.After != nil {
+		offset = sort.Search(len(matched), func(i int) bool {
+			return compareUserKey(page.Sort, matched[i], page.After.Value, page.After.ID, page.Desc) > 0
+		})
+	}
+
+	if offset >= len(matched) {
+		return []models.User{}, total, nil
+	}
+	end := offset + page.Limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[offset:end], total, nil
+}
+
+func (r userRepository) Search(ctx context.Context, terms []string, limit, offset int) ([]models.UserSearchHit, int, error) {
+	return repository.SearchUsers(ctx, r, terms, limit, offset)
+}
+
+// Helper function to apply a user filter like the WHERE clause of the SQL stores
+func userMatches(user models.User, filter repository.UserFilter) bool {
+	if user.MembershipID == models.SystemMembershipID {
+		return false
+	}
+	// Closed accounts are hidden unless requested
+	if filter.Status != "" && user.Status != filter.Status || filter.Status == "" && user.Status == models.UserClosed {
+		return false
+	}
+
+	for _, term := range filter.Search {
+		term = strings.ToLower(term)
+		found := false
+		phoneDigits := strings.NewReplacer("-", "", " ", "").Replace(user.PhoneNumber)
+		for _, field := range []string{user.FirstName, user.LastName, user.Email, user.PhoneNumber, user.MembershipID, phoneDigits} {
+			if strings.Contains(strings.ToLower(field), term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	switch {
+	case filter.MembershipLevel != "" && user.MembershipLevel != filter.MembershipLevel,
+		filter.MinPoints != nil && user.Points < *filter.MinPoints,
+		filter.MaxPoints != nil && user.Points > *filter.MaxPoints,
+		!filter.JoinedFrom.IsZero() && user.JoinedDate.Before(filter.JoinedFrom),
+		!filter.JoinedBefore.IsZero() && !user.JoinedDate.Before(filter.JoinedBefore):
+		return false
+	}
+	return true
+}
+
+// Helper function to order two users by the sort field, ties broken by ID
+func compareUsers(field repository.UserSortField, a, b models.User, desc bool) int {
+	return compareUserKey(field, a, field.Value(b), b.ID, desc)
+}
+
+// Helper function to compare a user with a sort position. The result is
+// negative when the user comes first in the requested order.
+func compareUserKey(field repository.UserSortField, user models.User, value interface{}, id int, desc bool) int {
+	result := 0
+	switch v := field.Value(user).(type) {
+	case int:
+		other, _ := value.(int)
+		result = v - other
+	case string:
+		other, _ := value.(string)
+		result = strings.Compare(v, other)
+	case time.Time:
+		other, _ := value.(time.Time)
+		result = v.Compare(other)
+	}
+	if result == 0 {
+		result = user.ID - id
+	}
+	if desc {
+		return -result
+	}
+	return result
+}
+
+// Helper function to check the unique columns of users
+func (st *state) userConflict(id int, membershipID, email string) bool {
+	for _, user := range st.users {
+		if user.ID != id && (user.MembershipID == membershipID || user.Email == email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r userRepository) Create(ctx context.Context, user *models.User) error {
+	defer r.s.lock()()
+	if r.s.st.userConflict(0, user.MembershipID, user.Email) {
+		return repository.ErrConflict
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	created := *user
+	created.ID = len(r.s.st.users) + 1
+	if created.Status == "" {
+		created.Status = models.UserActive
+	}
+	if created.JoinedDate.IsZero() {
+		created.JoinedDate = now
+	}
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	created.Version = 1
+
+	r.s.st.users[created.ID] = created
+	*user = created
+	return nil
+}
+
+func (r userRepository) Update(ctx context.Context, id int, req models.UpdateUserRequest) error {
+	defer r.s.lock()()
+	user, ok := r.s.st.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	if req.FirstName != "" {
+		user.FirstName = req.FirstName
+	}
+	if req.LastName != "" {
+		user.LastName = req.LastName
+	}
+	if req.PhoneNumber != "" {
+		user.PhoneNumber = req.PhoneNumber
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.MembershipLevel != "" {
+		user.MembershipLevel = req.MembershipLevel
+	}
+	if r.s.st.userConflict(id, user.MembershipID, user.Email) {
+		return repository.ErrConflict
+	}
+
+	user.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	user.Version++
+	r.s.st.users[id] = user
+	return nil
+}
+
+func (r userRepository) AdjustPoints(ctx context.Context, id int, delta int) (int, error) {
+	defer r.s.lock()()
+	user, ok := r.s.st.users[id]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	if user.Points+delta < 0 {
+		return 0, repository.ErrInsufficientPoints
+	}
+
+	user.Points += delta
+	user.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	user.Version++
+	r.s.st.users[id] = user
+	return user.Points, nil
+}
+
+func (r userRepository) SetStatus(ctx context.Context, id int, status models.UserStatus, at time.Time) error {
+	defer r.s.lock()()
+	user, ok := r.s.st.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	user.Status = status
+	user.ClosedAt = nil
+	if status == models.UserClosed {
+		closedAt := at.UTC().Truncate(time.Second)
+		user.ClosedAt = &closedAt
+	}
+	user.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	user.Version++
+	r.s.st.users[id] = user
+	return nil
+}
+
+func (r userRepository) NextMembershipID(ctx context.Context) (string, error) {
+	defer r.s.lock()()
+	return fmt.Sprintf("LBK%06d", len(r.s.st.users)+1), nil
+}
+
+func (r userRepository) AddStatusChange(ctx context.Context, change *models.UserStatusChange) error {
+	defer r.s.lock()()
+	change.ID = len(r.s.st.statusHistory) + 1
+	change.CreatedAt = change.CreatedAt.UTC().Truncate(time.Second)
+	r.s.st.statusHistory = append(r.s.st.statusHistory, *change)
+	return nil
+}
+
+func (r userRepository) ListStatusChanges(ctx context.Context, userID int) ([]models.UserStatusChange, error) {
+	defer r.s.lock()()
+	history := []models.UserStatusChange{}
+	for i := len(r.s.st.statusHistory) - 1; i >= 0; i-- {
+		if r.s.st.statusHistory[i].UserID == userID {
+			history = append(history, r.s.st.statusHistory[i])
+		}
+	}
+	return history, nil
+}
+
+func (r userRepository) PointsByStatus(ctx context.Context) (map[models.UserStatus]int, error) {
+	defer r.s.lock()()
+	totals := map[models.UserStatus]int{}
+	for _, user := range r.s.st.users {
+		if user.MembershipID != models.SystemMembershipID {
+			totals[user.Status] += user.Points
+		}
+	}
+	return totals, nil
+}
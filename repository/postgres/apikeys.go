@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// apiKeyColumns lists the api_keys columns read by scanAPIKey
+const apiKeyColumns = `id, key_id, name, secret_salt, scopes, created_at, rotated_at, revoked_at`
+
+type apiKeyRepository struct {
+	q querier
+}
+
+// Helper function to scan a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	err := row.Scan(&key.ID, &key.KeyID, &key.Name, &key.SecretSalt, &scopes, &key.CreatedAt, &key.RotatedAt, &key.RevokedAt)
+	if err == sql.ErrNoRows {
+		return models.APIKey{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	// Report timestamps in UTC like the SQLite store
+	key.Scopes = models.SplitScopes(scopes)
+	key.CreatedAt = key.CreatedAt.UTC()
+	if key.RotatedAt != nil {
+		rotatedAt := key.RotatedAt.UTC()
+		key.RotatedAt = &rotatedAt
+	}
+	if key.RevokedAt != nil {
+		revokedAt := key.RevokedAt.UTC()
+		key.RevokedAt = &revokedAt
+	}
+	return key, nil
+}
+
+func (r apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	err := r.q.QueryRowContext(ctx, `
+		INSERT INTO api_keys (key_id, name, secret_salt, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, key.KeyID, key.Name, key.SecretSalt, models.JoinScopes(key.Scopes), key.CreatedAt).Scan(&key.ID)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	return err
+}
+
+func (r apiKeyRepository) GetByID(ctx context.Context, id int) (models.APIKey, error) {
+	return scanAPIKey(r.q.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1", id))
+}
+
+func (r apiKeyRepository) GetByKeyID(ctx context.Context, keyID string) (models.APIKey, error) {
+	return scanAPIKey(r.q.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_id = $1", keyID))
+}
+
+func (r apiKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.q.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r apiKeyRepository) Rotate(ctx context.Context, id int, secretSalt string, at time.Time) error {
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE api_keys SET secret_salt = $1, rotated_at = $2 WHERE id = $3 AND revoked_at IS NULL
+	`, secretSalt, at, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL
+	`, at, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r apiKeyRepository) UseNonce(ctx context.Context, id int, nonce string, at time.Time) error {
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO api_key_nonces (api_key_id, nonce, created_at) VALUES ($1, $2, $3)
+	`, id, nonce, at)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	return err
+}
+
+func (r apiKeyRepository) PurgeNonces(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.q.ExecContext(ctx, "DELETE FROM api_key_nonces WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+)
+
+type auditRepository struct {
+	q querier
+}
+
+func (r auditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	var changes *string
+	if len(entry.Changes) > 0 {
+		data, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+		encoded := string(data)
+		changes = &encoded
+	}
+
+	var requestID *string
+	if entry.RequestID != "" {
+		requestID = &entry.RequestID
+	}
+
+	return r.q.QueryRowContext(ctx, `
+		INSERT INTO audit_log (actor, actor_role, action, entity_type, entity_id, changes, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, entry.Actor, entry.ActorRole, entry.Action, entry.EntityType, entry.EntityID, changes, requestID,
+		entry.CreatedAt).Scan(&entry.ID)
+}
+
+func (r auditRepository) List(ctx context.Context, filter repository.AuditFilter, limit, offset int) ([]models.AuditEntry, int, error) {
+	where, args := auditWhere(filter)
+
+	// Count total records
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, actor, actor_role, action, entity_type, entity_id, changes, request_id, created_at
+		FROM audit_log`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+strconv.Itoa(len(args)+1)+` OFFSET $`+strconv.Itoa(len(args)+2),
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var changes, requestID sql.NullString
+		err := rows.Scan(&entry.ID, &entry.Actor, &entry.ActorRole, &entry.Action, &entry.EntityType,
+			&entry.EntityID, &changes, &requestID, &entry.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		if changes.Valid {
+			if err := json.Unmarshal([]byte(changes.String), &entry.Changes); err != nil {
+				return nil, 0, err
+			}
+		}
+		entry.RequestID = requestID.String
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// Helper function to build the WHERE clause of an audit search
+func auditWhere(filter repository.AuditFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(column, op string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, column+" "+op+" $"+strconv.Itoa(len(args)))
+	}
+
+	if filter.Actor != "" {
+		add("actor", "=", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action", "=", filter.Action)
+	}
+	if filter.EntityType != "" {
+		add("entity_type", "=", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		add("entity_id", "=", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		add("request_id", "=", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at", ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at", "<=", filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+)
+
+type ledgerRepository struct {
+	q querier
+}
+
+func (r ledgerRepository) Append(ctx context.Context, entries ...models.PointLedger) error {
+	for _, e := range entries {
+		_, err := r.q.ExecContext(ctx, `
+			INSERT INTO point_ledger (user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, e.UserID, e.Change, e.BalanceAfter, e.EventType, e.TransferID, e.Reference, e.Metadata, e.CreatedAt)
+		if isUniqueViolation(err) {
+			return repository.ErrConflict
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r ledgerRepository) ListByUser(ctx context.Context, userID int) ([]models.PointLedger, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at
+		FROM point_ledger
+		WHERE user_id = $1
+		ORDER BY id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.PointLedger{}
+	for rows.Next() {
+		var e models.PointLedger
+		err := rows.Scan(&e.ID, &e.UserID, &e.Change, &e.BalanceAfter, &e.EventType, &e.TransferID, &e.Reference, &e.Metadata, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
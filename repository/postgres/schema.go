@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"temp-kbtg-backend/database/migrations"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/tracing"
+
+	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Open connects to PostgreSQL without creating or checking the schema
+func Open(dsn string) (*sql.DB, error) {
+	db, err := tracing.OpenDB("postgres", dsn, semconv.DBSystemPostgreSQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Test connection
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %v", err)
+	}
+	return db, nil
+}
+
+// Init applies pending migrations and creates the system account
+func Init(db *sql.DB) error {
+	migrator, err := migrations.New(db, migrations.Postgres)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to run against a schema written by a newer binary
+	if err = migrator.CheckVersion(context.Background()); err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, migration := range applied {
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	// Create the account that receives points forfeited by closed accounts
+	_, err = db.Exec(`
+		INSERT INTO users (membership_id, first_name, last_name, phone_number, email, membership_level, points, status)
+		VALUES ($1, 'System', 'Account', '-', 'system@kbtg.local', 'System', 0, 'frozen')
+		ON CONFLICT (membership_id) DO NOTHING
+	`, models.SystemMembershipID)
+	if err != nil {
+		return fmt.Errorf("failed to create system account: %v", err)
+	}
+	return nil
+}
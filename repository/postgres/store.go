@@ -0,0 +1,95 @@
+// Package postgres implements the repository interfaces on PostgreSQL.
+// Balance rows are locked with SELECT ... FOR UPDATE inside transactions.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"temp-kbtg-backend/repository"
+
+	"github.com/lib/pq"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Store is the PostgreSQL implementation of repository.Store
+type Store struct {
+	db *sql.DB // nil when the store is bound to a transaction
+	q  querier
+}
+
+// New creates a store backed by db
+func New(db *sql.DB) *Store {
+	return &Store{db: db, q: db}
+}
+
+func (s *Store) Users() repository.UserRepository {
+	return userRepository{q: s.q}
+}
+
+func (s *Store) Transfers() repository.TransferRepository {
+	return transferRepository{q: s.q}
+}
+
+func (s *Store) Ledger() repository.LedgerRepository {
+	return ledgerRepository{q: s.q}
+}
+
+func (s *Store) APIKeys() repository.APIKeyRepository {
+	return apiKeyRepository{q: s.q}
+}
+
+func (s *Store) Audit() repository.AuditRepository {
+	return auditRepository{q: s.q}
+}
+
+// WithinTx runs fn inside a READ COMMITTED transaction. Calls made on a
+// store that is already bound to a transaction join that transaction.
+func (s *Store) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{q: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Helper function to report unique constraint violations
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Helper function to turn an UPDATE that matched no rows into ErrNotFound
+func requireRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Store must satisfy repository.Store
+var _ repository.Store = (*Store)(nil)
@@ -0,0 +1,111 @@
+package postgres_test
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/repository/postgres"
+	"temp-kbtg-backend/repository/repositorytest"
+	"testing"
+)
+
+// TestStore runs the repository suite against PostgreSQL. POSTGRES_TEST_DSN
+// points it at a running server (as a postgres:// URL whose user may create
+// databases); otherwise a throwaway server is started with initdb and pg_ctl
+// from PATH. The test is skipped when neither is available.
+func TestStore(t *testing.T) {
+	dsn := testServer(t)
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		db := createDatabase(t, dsn)
+		if err := postgres.Init(db); err != nil {
+			t.Fatalf("init database: %v", err)
+		}
+		return postgres.New(db)
+	})
+}
+
+// Helper function to find or start a server and return the DSN of its admin database
+func testServer(t *testing.T) string {
+	t.Helper()
+	if dsn := os.Getenv("POSTGRES_TEST_DSN"); dsn != "" {
+		return dsn
+	}
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		t.Skip("PostgreSQL not available: set POSTGRES_TEST_DSN or put initdb and pg_ctl on PATH")
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		t.Skip("PostgreSQL not available: pg_ctl not found on PATH")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("PostgreSQL cannot be started as root: set POSTGRES_TEST_DSN instead")
+	}
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "--auth=trust", "-E", "UTF8").CombinedOutput(); err != nil {
+		t.Fatalf("initdb: %v\n%s", err, out)
+	}
+
+	port := freePort(t)
+	options := fmt.Sprintf("-p %d -c listen_addresses=127.0.0.1 -k %s", port, dir)
+	if out, err := exec.Command(pgCtl, "-D", data, "-l", filepath.Join(dir, "server.log"), "-o", options, "-w", "start").CombinedOutput(); err != nil {
+		t.Fatalf("pg_ctl start: %v\n%s", err, out)
+	}
+	t.Cleanup(func() {
+		exec.Command(pgCtl, "-D", data, "-m", "immediate", "stop").Run()
+	})
+	return fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+}
+
+// Helper function to pick a TCP port nobody listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("find a free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// Helper function to create an empty database on the server, dropped when the test ends
+func createDatabase(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	admin, err := postgres.Open(dsn)
+	if err != nil {
+		t.Fatalf("connect to %s: %v", dsn, err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	name := "kbtg_test_" + hex.EncodeToString(suffix)
+	if _, err = admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("POSTGRES_TEST_DSN must be a postgres:// URL: %v", err)
+	}
+	u.Path = "/" + name
+	db, err := postgres.Open(u.String())
+	if err != nil {
+		t.Fatalf("connect to %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		admin.Exec("DROP DATABASE IF EXISTS " + name)
+	})
+	return db
+}
@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// transferColumns lists the transfers columns read by scanTransfer
+const transferColumns = `t.id, t.from_user_id, t.to_user_id, t.amount, t.status, t.note, t.idempotency_key,
+		       t.created_at, t.updated_at, t.completed_at, t.fail_reason`
+
+// activeTransferStatuses are the statuses counted by the fraud rules
+const activeTransferStatuses = "('pending', 'processing', 'completed')"
+
+type transferRepository struct {
+	q querier
+}
+
+// Helper function to scan a row selected with transferColumns
+func scanTransfer(row rowScanner) (models.Transfer, error) {
+	var t models.Transfer
+	var id int
+	var note, failReason sql.NullString
+	var completedAt sql.NullTime
+
+	err := row.Scan(&id, &t.FromUserID, &t.ToUserID, &t.Amount, &t.Status, &note, &t.IdemKey,
+		&t.CreatedAt, &t.UpdatedAt, &completedAt, &failReason)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Transfer{}, repository.ErrNotFound
+		}
+		return models.Transfer{}, err
+	}
+
+	t.TransferID = &id
+	if note.Valid {
+		t.Note = &note.String
+	}
+	if failReason.Valid {
+		t.FailReason = &failReason.String
+	}
+
+	// Report timestamps in UTC like the SQLite store
+	t.CreatedAt = t.CreatedAt.UTC()
+	t.UpdatedAt = t.UpdatedAt.UTC()
+	if completedAt.Valid {
+		completed := completedAt.Time.UTC()
+		t.CompletedAt = &completed
+	}
+
+	return t, nil
+}
+
+func (r transferRepository) Create(ctx context.Context, t *models.Transfer) error {
+	var id int
+	err := r.q.QueryRowContext(ctx, `
+		INSERT INTO transfers (from_user_id, to_user_id, amount, status, note, idempotency_key, created_at, updated_at, completed_at, fail_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, t.FromUserID, t.ToUserID, t.Amount, t.Status, t.Note, t.IdemKey, t.CreatedAt, t.UpdatedAt, t.CompletedAt, t.FailReason).Scan(&id)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	t.TransferID = &id
+	return nil
+}
+
+func (r transferRepository) GetByIdemKey(ctx context.Context, idemKey string) (models.Transfer, error) {
+	return scanTransfer(r.q.QueryRowContext(ctx, "SELECT "+transferColumns+" FROM transfers t WHERE t.idempotency_key = $1", idemKey))
+}
+
+func (r transferRepository) ListByUser(ctx context.Context, userID, limit, offset int) ([]models.Transfer, int, error) {
+	// Count total records
+	var total int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transfers
+		WHERE from_user_id = $1 OR to_user_id = $1
+	`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT `+transferColumns+`
+		FROM transfers t
+		WHERE t.from_user_id = $1 OR t.to_user_id = $1
+		ORDER BY t.created_at DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transfers := []models.Transfer{}
+	for rows.Next() {
+		t, err := scanTransfer(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, total, rows.Err()
+}
+
+func (r transferRepository) UpdateStatus(ctx context.Context, id int, status models.TransferStatus, at time.Time, failReason *string) error {
+	var completedAt *time.Time
+	if status == models.StatusCompleted {
+		completedAt = &at
+	}
+
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE transfers SET status = $1, updated_at = $2, completed_at = COALESCE($3, completed_at), fail_reason = COALESCE($4, fail_reason)
+		WHERE id = $5
+	`, status, at, completedAt, failReason, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r transferRepository) CountSentSince(ctx context.Context, fromUserID int, since time.Time) (int, error) {
+	var count int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transfers
+		WHERE from_user_id = $1 AND created_at >= $2 AND status IN `+activeTransferStatuses,
+		fromUserID, since).Scan(&count)
+	return count, err
+}
+
+func (r transferRepository) CountCompletedBetween(ctx context.Context, fromUserID, toUserID int) (int, error) {
+	var count int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transfers
+		WHERE from_user_id = $1 AND to_user_id = $2 AND status = $3
+	`, fromUserID, toUserID, models.StatusCompleted).Scan(&count)
+	return count, err
+}
+
+func (r transferRepository) CountSendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (int, error) {
+	var count int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT from_user_id) FROM transfers
+		WHERE to_user_id = $1 AND from_user_id <> $2 AND created_at >= $3 AND status IN `+activeTransferStatuses,
+		toUserID, excludeUserID, since).Scan(&count)
+	return count, err
+}
+
+// reviewColumns lists the transfer_reviews columns read by scanReview
+const reviewColumns = `r.id, r.transfer_id, r.rule, r.reason, r.status, r.resolution_note, r.created_at, r.resolved_at`
+
+// Helper function to scan a row selected with reviewColumns followed by transferColumns
+func scanReview(row rowScanner) (models.TransferReview, error) {
+	var r models.TransferReview
+	var note sql.NullString
+	var resolvedAt sql.NullTime
+
+	transfer, err := scanTransfer(prefixScanner{row: row, leading: []interface{}{
+		&r.ID, &r.TransferID, &r.Rule, &r.Reason, &r.Status, &note, &r.CreatedAt, &resolvedAt,
+	}})
+	if err != nil {
+		return models.TransferReview{}, err
+	}
+
+	if note.Valid {
+		r.ResolutionNote = &note.String
+	}
+	r.CreatedAt = r.CreatedAt.UTC()
+	if resolvedAt.Valid {
+		resolved := resolvedAt.Time.UTC()
+		r.ResolvedAt = &resolved
+	}
+	r.Transfer = &transfer
+	return r, nil
+}
+
+// prefixScanner scans extra leading columns before the destinations it is given
+type prefixScanner struct {
+	row     rowScanner
+	leading []interface{}
+}
+
+func (p prefixScanner) Scan(dest ...interface{}) error {
+	return p.row.Scan(append(append([]interface{}{}, p.leading...), dest...)...)
+}
+
+func (r transferRepository) CreateReview(ctx context.Context, review *models.TransferReview) error {
+	return r.q.QueryRowContext(ctx, `
+		INSERT INTO transfer_reviews (transfer_id, rule, reason, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, review.TransferID, review.Rule, review.Reason, review.Status, review.CreatedAt).Scan(&review.ID)
+}
+
+func (r transferRepository) GetReview(ctx context.Context, id int) (models.TransferReview, error) {
+	// Lock the review so two reviewers cannot resolve it at the same time
+	return scanReview(r.q.QueryRowContext(ctx, `
+		SELECT `+reviewColumns+`, `+transferColumns+`
+		FROM transfer_reviews r
+		JOIN transfers t ON t.id = r.transfer_id
+		WHERE r.id = $1
+		FOR UPDATE OF r
+	`, id))
+}
+
+func (r transferRepository) ListReviews(ctx context.Context, status models.ReviewStatus) ([]models.TransferReview, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT `+reviewColumns+`, `+transferColumns+`
+		FROM transfer_reviews r
+		JOIN transfers t ON t.id = r.transfer_id
+		WHERE r.status = $1
+		ORDER BY r.created_at ASC
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.TransferReview{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r transferRepository) ResolveReview(ctx context.Context, id int, status models.ReviewStatus, note *string, at time.Time) error {
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE transfer_reviews SET status = $1, resolution_note = $2, resolved_at = $3 WHERE id = $4
+	`, status, note, at, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
@@ -0,0 +1,318 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// userColumns lists the users columns read by scanUser
+const userColumns = `id, membership_id, first_name, last_name, phone_number, email,
+		       membership_level, points, status, closed_at, joined_date, created_at, updated_at, version`
+
+type userRepository struct {
+	q querier
+}
+
+// Helper function to scan a row selected with userColumns
+func scanUser(row rowScanner) (models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.MembershipID,
+		&user.FirstName,
+		&user.LastName,
+		&user.PhoneNumber,
+		&user.Email,
+		&user.MembershipLevel,
+		&user.Points,
+		&user.Status,
+		&user.ClosedAt,
+		&user.JoinedDate,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
+	)
+	if err == sql.ErrNoRows {
+		return models.User{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// Report timestamps in UTC like the SQLite store
+	user.JoinedDate = user.JoinedDate.UTC()
+	user.CreatedAt = user.CreatedAt.UTC()
+	user.UpdatedAt = user.UpdatedAt.UTC()
+	if user.ClosedAt != nil {
+		closedAt := user.ClosedAt.UTC()
+		user.ClosedAt = &closedAt
+	}
+	return user, nil
+}
+
+func (r userRepository) GetByID(ctx context.Context, id int) (models.User, error) {
+	return scanUser(r.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+func (r userRepository) GetByIDForUpdate(ctx context.Context, id int) (models.User, error) {
+	return scanUser(r.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1 FOR UPDATE", id))
+}
+
+func (r userRepository) GetByMembershipID(ctx context.Context, membershipID string) (models.User, error) {
+	return scanUser(r.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE membership_id = $1", membershipID))
+}
+
+func (r userRepository) List(ctx context.Context, filter repository.UserFilter, page repository.UserPage) ([]models.User, int, error) {
+	where, args := userWhere(filter)
+
+	// Count total records
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	column, direction, compare := string(repository.UserSortID), "ASC", ">"
+	if page.Sort.IsValid() {
+		column = string(page.Sort)
+	}
+	if page.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	// Keyset pagination continues after the cursor instead of skipping rows
+	offset := page.Offset
+	if page.After != nil {
+		args = append(args, page.After.Value, page.After.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", column, compare, len(args)-1, len(args))
+		offset = 0
+	}
+
+	args = append(args, page.Limit, offset)
+	rows, err := r.q.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM users%s ORDER BY %s %s, id %s LIMIT $%d OFFSET $%d",
+		userColumns, where, column, direction, direction, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+func (r userRepository) Search(ctx context.Context, terms []string, limit, offset int) ([]models.UserSearchHit, int, error) {
+	return repository.SearchUsers(ctx, r, terms, limit, offset)
+}
+
+// Helper function to build the WHERE clause of a user listing
+func userWhere(filter repository.UserFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	add("membership_id <> ?", models.SystemMembershipID)
+
+	// Closed accounts are hidden unless requested
+	if filter.Status != "" {
+		add("status = ?", filter.Status)
+	} else {
+		add("status <> ?", models.UserClosed)
+	}
+
+	for _, term := range filter.Search {
+		add(`(first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ? OR phone_number ILIKE ? OR membership_id ILIKE ?
+			OR replace(replace(phone_number, '-', ''), ' ', '') ILIKE ?)`,
+			likePattern(term))
+	}
+	if filter.MembershipLevel != "" {
+		add("membership_level = ?", filter.MembershipLevel)
+	}
+	if filter.MinPoints != nil {
+		add("points >= ?", *filter.MinPoints)
+	}
+	if filter.MaxPoints != nil {
+		add("points <= ?", *filter.MaxPoints)
+	}
+	if !filter.JoinedFrom.IsZero() {
+		add("joined_date >= ?", filter.JoinedFrom)
+	}
+	if !filter.JoinedBefore.IsZero() {
+		add("joined_date < ?", filter.JoinedBefore)
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Helper function to build an ILIKE pattern matching term anywhere, with its
+// wildcards escaped by the default backslash escape
+func likePattern(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term) + "%"
+}
+
+func (r userRepository) Create(ctx context.Context, user *models.User) error {
+	if user.Status == "" {
+		user.Status = models.UserActive
+	}
+
+	created, err := scanUser(r.q.QueryRowContext(ctx, `
+		INSERT INTO users (membership_id, first_name, last_name, phone_number, email, membership_level, points, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+userColumns,
+		user.MembershipID, user.FirstName, user.LastName, user.PhoneNumber, user.Email, user.MembershipLevel, user.Points, user.Status))
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	*user = created
+	return nil
+}
+
+func (r userRepository) Update(ctx context.Context, id int, req models.UpdateUserRequest) error {
+	// Build update query dynamically
+	updates := []string{}
+	args := []interface{}{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		updates = append(updates, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if req.FirstName != "" {
+		set("first_name", req.FirstName)
+	}
+	if req.LastName != "" {
+		set("last_name", req.LastName)
+	}
+	if req.PhoneNumber != "" {
+		set("phone_number", req.PhoneNumber)
+	}
+	if req.Email != "" {
+		set("email", req.Email)
+	}
+	if req.MembershipLevel != "" {
+		set("membership_level", req.MembershipLevel)
+	}
+
+	updates = append(updates, "updated_at = now(), version = version + 1")
+	args = append(args, id)
+
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id = $%d", strings.Join(updates, ", "), len(args))
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r userRepository) AdjustPoints(ctx context.Context, id int, delta int) (int, error) {
+	// The balance check and the write are a single statement, so concurrent
+	// debits can never take the balance below zero
+	var balance int
+	err := r.q.QueryRowContext(ctx, `
+		UPDATE users SET points = points + $1, updated_at = now(), version = version + 1
+		WHERE id = $2 AND points + $1 >= 0
+		RETURNING points
+	`, delta, id).Scan(&balance)
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return 0, err
+		}
+		return 0, repository.ErrInsufficientPoints
+	}
+	return balance, err
+}
+
+func (r userRepository) SetStatus(ctx context.Context, id int, status models.UserStatus, at time.Time) error {
+	var closedAt *time.Time
+	if status == models.UserClosed {
+		closedAt = &at
+	}
+
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE users SET status = $1, closed_at = $2, updated_at = now(), version = version + 1 WHERE id = $3
+	`, status, closedAt, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r userRepository) NextMembershipID(ctx context.Context) (string, error) {
+	var lastID int
+	err := r.q.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM users").Scan(&lastID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("LBK%06d", lastID+1), nil
+}
+
+func (r userRepository) AddStatusChange(ctx context.Context, change *models.UserStatusChange) error {
+	change.CreatedAt = change.CreatedAt.UTC().Truncate(time.Second)
+	return r.q.QueryRowContext(ctx, `
+		INSERT INTO user_status_history (user_id, from_status, to_status, reason, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, change.UserID, change.FromStatus, change.ToStatus, change.Reason, change.Actor, change.CreatedAt).Scan(&change.ID)
+}
+
+func (r userRepository) ListStatusChanges(ctx context.Context, userID int) ([]models.UserStatusChange, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, user_id, from_status, to_status, reason, actor, created_at
+		FROM user_status_history
+		WHERE user_id = $1
+		ORDER BY id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.UserStatusChange{}
+	for rows.Next() {
+		var change models.UserStatusChange
+		err := rows.Scan(&change.ID, &change.UserID, &change.FromStatus, &change.ToStatus, &change.Reason, &change.Actor, &change.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		change.CreatedAt = change.CreatedAt.UTC()
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+func (r userRepository) PointsByStatus(ctx context.Context) (map[models.UserStatus]int, error) {
+	rows, err := r.q.QueryContext(ctx, "SELECT status, COALESCE(SUM(points), 0) FROM users WHERE membership_id != $1 GROUP BY status", models.SystemMembershipID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[models.UserStatus]int{}
+	for rows.Next() {
+		var status models.UserStatus
+		var points int
+		if err := rows.Scan(&status, &points); err != nil {
+			return nil, err
+		}
+		totals[status] = points
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,207 @@
+// Package repository defines the storage interfaces used by the handlers.
+// The sqlite and postgres packages implement them on top of database/sql and
+// the memory package provides an in-memory fake for tests. The
+// repositorytest package checks that all three behave the same.
+package repository
+
+import (
+	"context"
+	"errors"
+	"temp-kbtg-backend/models"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a unique constraint is violated
+	ErrConflict = errors.New("conflict")
+	// ErrInsufficientPoints is returned by AdjustPoints when a debit would make the balance negative
+	ErrInsufficientPoints = errors.New("insufficient points")
+)
+
+// UserFilter narrows the users returned by UserRepository.List.
+// The system account is never listed. An empty Status lists every account
+// that is not closed, other zero fields match everything.
+type UserFilter struct {
+	Status models.UserStatus
+	// Search holds free-text terms. Every term must appear, case-insensitively,
+	// in the first or last name, email, phone number (with or without its
+	// dashes and spaces) or membership ID.
+	Search          []string
+	MembershipLevel string
+	MinPoints       *int
+	MaxPoints       *int
+	JoinedFrom      time.Time // inclusive
+	JoinedBefore    time.Time // exclusive
+}
+
+// UserSortField names a column users can be sorted by
+type UserSortField string
+
+const (
+	UserSortID           UserSortField = "id"
+	UserSortMembershipID UserSortField = "membership_id"
+	UserSortFirstName    UserSortField = "first_name"
+	UserSortLastName     UserSortField = "last_name"
+	UserSortPoints       UserSortField = "points"
+	UserSortJoinedDate   UserSortField = "joined_date"
+)
+
+// IsValid reports whether the field is a sortable column
+func (f UserSortField) IsValid() bool {
+	switch f {
+	case UserSortID, UserSortMembershipID, UserSortFirstName, UserSortLastName, UserSortPoints, UserSortJoinedDate:
+		return true
+	}
+	return false
+}
+
+// Value returns the sort key of a user: an int for id and points, a
+// time.Time for joined_date and a string otherwise
+func (f UserSortField) Value(user models.User) interface{} {
+	switch f {
+	case UserSortMembershipID:
+		return user.MembershipID
+	case UserSortFirstName:
+		return user.FirstName
+	case UserSortLastName:
+		return user.LastName
+	case UserSortPoints:
+		return user.Points
+	case UserSortJoinedDate:
+		return user.JoinedDate
+	}
+	return user.ID
+}
+
+// UserPage selects a page of users ordered by Sort, ties broken by ID in the
+// same direction. When After is set the page starts right after that
+// position (keyset pagination) and Offset is ignored.
+type UserPage struct {
+	Sort   UserSortField
+	Desc   bool
+	Limit  int
+	Offset int
+	After  *UserCursor
+}
+
+// UserCursor is the position of the last user of the previous page
+type UserCursor struct {
+	Value interface{} // the UserSortField.Value of that user
+	ID    int
+}
+
+// UserRepository stores users and their account status history
+type UserRepository interface {
+	GetByID(ctx context.Context, id int) (models.User, error)
+	// GetByIDForUpdate reads a user and locks its balance row until the
+	// transaction ends. Outside a transaction it behaves like GetByID.
+	GetByIDForUpdate(ctx context.Context, id int) (models.User, error)
+	GetByMembershipID(ctx context.Context, membershipID string) (models.User, error)
+	// List returns a page of matching users and the total count of matches
+	List(ctx context.Context, filter UserFilter, page UserPage) ([]models.User, int, error)
+	// Search returns a page of the users that are not closed and match every
+	// term, best match first, and the total count of matches
+	Search(ctx context.Context, terms []string, limit, offset int) ([]models.UserSearchHit, int, error)
+	// Create inserts the user and fills in its ID and timestamps
+	Create(ctx context.Context, user *models.User) error
+	// Update changes the non-empty profile fields of req. Points is ignored,
+	// balances only change through AdjustPoints so each change has a ledger entry.
+	Update(ctx context.Context, id int, req models.UpdateUserRequest) error
+	// AdjustPoints adds delta to the balance and returns the new balance.
+	// A debit that would make the balance negative fails with ErrInsufficientPoints.
+	AdjustPoints(ctx context.Context, id int, delta int) (int, error)
+	// SetStatus changes the account status, closing an account also sets closed_at
+	SetStatus(ctx context.Context, id int, status models.UserStatus, at time.Time) error
+	NextMembershipID(ctx context.Context) (string, error)
+	// AddStatusChange records a status transition and fills in its ID
+	AddStatusChange(ctx context.Context, change *models.UserStatusChange) error
+	ListStatusChanges(ctx context.Context, userID int) ([]models.UserStatusChange, error)
+	// PointsByStatus sums the balances of member accounts (not the system account) per status
+	PointsByStatus(ctx context.Context) (map[models.UserStatus]int, error)
+}
+
+// TransferRepository stores transfers and the review queue
+type TransferRepository interface {
+	// Create inserts the transfer and fills in its TransferID
+	Create(ctx context.Context, transfer *models.Transfer) error
+	GetByIdemKey(ctx context.Context, idemKey string) (models.Transfer, error)
+	// ListByUser returns a page of transfers sent or received by the user, newest first, and the total count
+	ListByUser(ctx context.Context, userID, limit, offset int) ([]models.Transfer, int, error)
+	// UpdateStatus sets the status, completed_at and fail_reason of a transfer
+	UpdateStatus(ctx context.Context, id int, status models.TransferStatus, at time.Time, failReason *string) error
+
+	// CountSentSince counts active transfers sent by a user since the given time
+	CountSentSince(ctx context.Context, fromUserID int, since time.Time) (int, error)
+	// CountCompletedBetween counts completed transfers from one user to another
+	CountCompletedBetween(ctx context.Context, fromUserID, toUserID int) (int, error)
+	// CountSendersSince counts distinct senders, other than excludeUserID, to a user since the given time
+	CountSendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (int, error)
+
+	// CreateReview inserts a review and fills in its ID
+	CreateReview(ctx context.Context, review *models.TransferReview) error
+	// GetReview returns the review with its transfer attached
+	GetReview(ctx context.Context, id int) (models.TransferReview, error)
+	// ListReviews returns reviews with the given status, oldest first, with their transfers attached
+	ListReviews(ctx context.Context, status models.ReviewStatus) ([]models.TransferReview, error)
+	ResolveReview(ctx context.Context, id int, status models.ReviewStatus, note *string, at time.Time) error
+}
+
+// LedgerRepository stores point_ledger entries
+type LedgerRepository interface {
+	// Append inserts the entries. An earn or redeem entry whose reference is
+	// already used fails with ErrConflict.
+	Append(ctx context.Context, entries ...models.PointLedger) error
+	ListByUser(ctx context.Context, userID int) ([]models.PointLedger, error)
+}
+
+// APIKeyRepository stores partner API keys and the nonces of signed requests
+type APIKeyRepository interface {
+	// Create inserts the key and fills in its ID. A duplicate key ID fails with ErrConflict.
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id int) (models.APIKey, error)
+	GetByKeyID(ctx context.Context, keyID string) (models.APIKey, error)
+	// List returns every key, oldest first
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Rotate replaces the secret salt of a key that is not revoked
+	Rotate(ctx context.Context, id int, secretSalt string, at time.Time) error
+	// Revoke disables a key that is not revoked yet
+	Revoke(ctx context.Context, id int, at time.Time) error
+	// UseNonce records a nonce for the key. A nonce seen before fails with ErrConflict.
+	UseNonce(ctx context.Context, id int, nonce string, at time.Time) error
+	// PurgeNonces deletes nonces recorded before the given time and returns how many were removed
+	PurgeNonces(ctx context.Context, before time.Time) (int, error)
+}
+
+// AuditFilter narrows the entries returned by AuditRepository.List.
+// Zero fields match everything; From and To bound created_at inclusively.
+type AuditFilter struct {
+	Actor      string
+	Action     models.AuditAction
+	EntityType string
+	EntityID   int
+	RequestID  string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditRepository stores the audit log of data-changing actions
+type AuditRepository interface {
+	// Append inserts the entry and fills in its ID
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	// List returns a page of matching entries, newest first, and the total count
+	List(ctx context.Context, filter AuditFilter, limit, offset int) ([]models.AuditEntry, int, error)
+}
+
+// Store groups the repositories and runs them inside a transaction
+type Store interface {
+	Users() UserRepository
+	Transfers() TransferRepository
+	Ledger() LedgerRepository
+	APIKeys() APIKeyRepository
+	Audit() AuditRepository
+	// WithinTx runs fn with a Store bound to a single transaction.
+	// The transaction is committed when fn returns nil and rolled back otherwise.
+	WithinTx(ctx context.Context, fn func(tx Store) error) error
+}
@@ -0,0 +1,495 @@
+// Package repositorytest is a conformance suite for repository.Store
+// implementations. Every backend runs the same tests from its own _test.go
+// file, so the memory, SQLite and PostgreSQL stores keep the same behavior.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"testing"
+	"time"
+)
+
+// OpenFunc returns an empty, migrated store. It is called once per test and
+// should register its own cleanup with t.Cleanup.
+type OpenFunc func(t *testing.T) repository.Store
+
+// Run runs the whole suite against the stores returned by open
+func Run(t *testing.T, open OpenFunc) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store repository.Store)
+	}{
+		{"Users", testUsers},
+		{"UserList", testUserList},
+		{"AdjustPoints", testAdjustPoints},
+		{"UserStatus", testUserStatus},
+		{"Transfers", testTransfers},
+		{"Reviews", testReviews},
+		{"Ledger", testLedger},
+		{"APIKeys", testAPIKeys},
+		{"Nonces", testNonces},
+		{"Audit", testAudit},
+		{"WithinTx", testWithinTx},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, open(t))
+		})
+	}
+}
+
+// now is a fixed, second-precision time, as the stores keep timestamps in seconds
+var now = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+// Helper function to create an active Bronze member with the given points
+func createUser(t *testing.T, store repository.Store, n, points int) models.User {
+	t.Helper()
+	user := models.User{
+		MembershipID:    fmt.Sprintf("TST%06d", n),
+		FirstName:       fmt.Sprintf("First%d", n),
+		LastName:        fmt.Sprintf("Last%d", n),
+		PhoneNumber:     fmt.Sprintf("081-234-%04d", n),
+		Email:           fmt.Sprintf("member%d@example.com", n),
+		MembershipLevel: models.LevelBronze,
+		Points:          points,
+	}
+	if err := store.Users().Create(context.Background(), &user); err != nil {
+		t.Fatalf("create user %d: %v", n, err)
+	}
+	return user
+}
+
+// Helper function to create a completed transfer
+func createTransfer(t *testing.T, store repository.Store, from, to, amount int, at time.Time) models.Transfer {
+	t.Helper()
+	transfer := models.Transfer{
+		IdemKey:     fmt.Sprintf("idem-%d-%d-%d-%d", from, to, amount, at.Unix()),
+		FromUserID:  from,
+		ToUserID:    to,
+		Amount:      amount,
+		Status:      models.StatusCompleted,
+		CreatedAt:   at,
+		UpdatedAt:   at,
+		CompletedAt: &at,
+	}
+	if err := store.Transfers().Create(context.Background(), &transfer); err != nil {
+		t.Fatalf("create transfer: %v", err)
+	}
+	return transfer
+}
+
+func testUsers(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	user := createUser(t, store, 1, 100)
+	if user.ID == 0 || user.Status != models.UserActive || user.Version != 1 {
+		t.Errorf("created user has id %d, status %q, version %d", user.ID, user.Status, user.Version)
+	}
+
+	got, err := store.Users().GetByID(ctx, user.ID)
+	if err != nil || got.Email != user.Email || got.Points != 100 {
+		t.Errorf("GetByID returned %+v, %v", got, err)
+	}
+	if got, err = store.Users().GetByMembershipID(ctx, user.MembershipID); err != nil || got.ID != user.ID {
+		t.Errorf("GetByMembershipID returned %+v, %v", got, err)
+	}
+	if _, err = store.Users().GetByID(ctx, 9999); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByID of a missing user returned %v, want ErrNotFound", err)
+	}
+
+	duplicate := models.User{MembershipID: user.MembershipID, FirstName: "A", LastName: "B", Email: "other@example.com", MembershipLevel: models.LevelBronze}
+	if err = store.Users().Create(ctx, &duplicate); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("duplicate membership ID returned %v, want ErrConflict", err)
+	}
+
+	// Points are left to AdjustPoints
+	points := 250
+	if err = store.Users().Update(ctx, user.ID, models.UpdateUserRequest{FirstName: "Renamed", Points: &points}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, _ = store.Users().GetByID(ctx, user.ID)
+	if got.FirstName != "Renamed" || got.LastName != user.LastName || got.Points != user.Points || got.Version != 2 {
+		t.Errorf("updated user is %+v", got)
+	}
+	if err = store.Users().Update(ctx, 9999, models.UpdateUserRequest{FirstName: "X"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("update of a missing user returned %v, want ErrNotFound", err)
+	}
+
+	// Membership IDs continue after the highest user ID
+	next, err := store.Users().NextMembershipID(ctx)
+	if err != nil || next != fmt.Sprintf("LBK%06d", user.ID+1) {
+		t.Errorf("NextMembershipID returned %q, %v", next, err)
+	}
+}
+
+func testUserList(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	for n := 1; n <= 5; n++ {
+		createUser(t, store, n, n*100)
+	}
+	closed := createUser(t, store, 6, 0)
+	if err := store.Users().SetStatus(ctx, closed.ID, models.UserClosed, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// The system account and closed accounts are not listed by default
+	users, total, err := store.Users().List(ctx, repository.UserFilter{}, repository.UserPage{Limit: 10})
+	if err != nil || total != 5 || len(users) != 5 {
+		t.Fatalf("List returned %d of %d users, %v, want 5", len(users), total, err)
+	}
+	if _, total, _ = store.Users().List(ctx, repository.UserFilter{Status: models.UserClosed}, repository.UserPage{Limit: 10}); total != 1 {
+		t.Errorf("closed filter matched %d users, want 1", total)
+	}
+
+	minPoints, maxPoints := 200, 400
+	users, total, _ = store.Users().List(ctx, repository.UserFilter{MinPoints: &minPoints, MaxPoints: &maxPoints},
+		repository.UserPage{Sort: repository.UserSortPoints, Desc: true, Limit: 2})
+	if total != 3 || len(users) != 2 || users[0].Points != 400 || users[1].Points != 300 {
+		t.Errorf("points filter returned %d of %d users: %+v", len(users), total, users)
+	}
+
+	// Keyset pagination continues after the cursor
+	cursor := &repository.UserCursor{Value: repository.UserSortPoints.Value(users[1]), ID: users[1].ID}
+	users, _, _ = store.Users().List(ctx, repository.UserFilter{MinPoints: &minPoints, MaxPoints: &maxPoints},
+		repository.UserPage{Sort: repository.UserSortPoints, Desc: true, Limit: 2, After: cursor})
+	if len(users) != 1 || users[0].Points != 200 {
+		t.Errorf("next page is %+v, want the user with 200 points", users)
+	}
+
+	// Search terms match case-insensitively, phone numbers also without dashes
+	for _, terms := range [][]string{{"first3"}, {"MEMBER3@"}, {"0812340003"}, {"TST000003"}} {
+		users, total, err = store.Users().List(ctx, repository.UserFilter{Search: terms}, repository.UserPage{Limit: 10})
+		if err != nil || total != 1 || users[0].MembershipID != "TST000003" {
+			t.Errorf("search %q matched %d users, %v", terms, total, err)
+		}
+	}
+	hits, total, err := store.Users().Search(ctx, []string{"last4"}, 10, 0)
+	if err != nil || total != 1 || len(hits) != 1 || hits[0].LastName != "Last4" {
+		t.Errorf("Search returned %d hits, %v", total, err)
+	}
+	if _, total, _ = store.Users().Search(ctx, []string{"last6"}, 10, 0); total != 0 {
+		t.Errorf("Search found %d closed accounts, want none", total)
+	}
+}
+
+func testAdjustPoints(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	user := createUser(t, store, 1, 100)
+
+	balance, err := store.Users().AdjustPoints(ctx, user.ID, -60)
+	if err != nil || balance != 40 {
+		t.Errorf("debit returned %d, %v, want 40", balance, err)
+	}
+	if _, err = store.Users().AdjustPoints(ctx, user.ID, -41); !errors.Is(err, repository.ErrInsufficientPoints) {
+		t.Errorf("overdraft returned %v, want ErrInsufficientPoints", err)
+	}
+	if balance, err = store.Users().AdjustPoints(ctx, user.ID, 10); err != nil || balance != 50 {
+		t.Errorf("credit returned %d, %v, want 50", balance, err)
+	}
+	if _, err = store.Users().AdjustPoints(ctx, 9999, 10); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("adjusting a missing user returned %v, want ErrNotFound", err)
+	}
+}
+
+func testUserStatus(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	user := createUser(t, store, 1, 100)
+	createUser(t, store, 2, 50)
+
+	if err := store.Users().SetStatus(ctx, user.ID, models.UserFrozen, now); err != nil {
+		t.Fatal(err)
+	}
+	totals, err := store.Users().PointsByStatus(ctx)
+	if err != nil || totals[models.UserFrozen] != 100 || totals[models.UserActive] != 50 {
+		t.Errorf("PointsByStatus returned %v, %v", totals, err)
+	}
+
+	if err = store.Users().SetStatus(ctx, user.ID, models.UserClosed, now); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := store.Users().GetByID(ctx, user.ID)
+	if got.Status != models.UserClosed || got.ClosedAt == nil || !got.ClosedAt.Equal(now) {
+		t.Errorf("closed user has status %q and closed_at %v", got.Status, got.ClosedAt)
+	}
+	if err = store.Users().SetStatus(ctx, 9999, models.UserFrozen, now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("SetStatus of a missing user returned %v, want ErrNotFound", err)
+	}
+
+	for i, to := range []models.UserStatus{models.UserFrozen, models.UserClosed} {
+		change := models.UserStatusChange{UserID: user.ID, FromStatus: models.UserActive, ToStatus: to, Reason: "test", Actor: "alice", CreatedAt: now.Add(time.Duration(i) * time.Minute)}
+		if err = store.Users().AddStatusChange(ctx, &change); err != nil || change.ID == 0 {
+			t.Fatalf("AddStatusChange returned id %d, %v", change.ID, err)
+		}
+	}
+	history, err := store.Users().ListStatusChanges(ctx, user.ID)
+	if err != nil || len(history) != 2 || history[0].ToStatus != models.UserClosed || history[0].Actor != "alice" {
+		t.Errorf("ListStatusChanges returned %+v, %v, want newest first", history, err)
+	}
+}
+
+func testTransfers(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	a, b, c := createUser(t, store, 1, 0), createUser(t, store, 2, 0), createUser(t, store, 3, 0)
+
+	first := createTransfer(t, store, a.ID, b.ID, 10, now.Add(-2*time.Hour))
+	createTransfer(t, store, a.ID, b.ID, 20, now.Add(-30*time.Minute))
+	createTransfer(t, store, c.ID, b.ID, 30, now.Add(-10*time.Minute))
+	if first.TransferID == nil {
+		t.Fatal("Create did not set the transfer ID")
+	}
+
+	got, err := store.Transfers().GetByIdemKey(ctx, first.IdemKey)
+	if err != nil || got.Amount != 10 || !got.CreatedAt.Equal(first.CreatedAt) || got.CompletedAt == nil {
+		t.Errorf("GetByIdemKey returned %+v, %v", got, err)
+	}
+	if _, err = store.Transfers().GetByIdemKey(ctx, "missing"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByIdemKey of a missing transfer returned %v, want ErrNotFound", err)
+	}
+
+	transfers, total, err := store.Transfers().ListByUser(ctx, b.ID, 2, 0)
+	if err != nil || total != 3 || len(transfers) != 2 || transfers[0].Amount != 30 {
+		t.Errorf("ListByUser returned %d of %d, %v, want newest first", len(transfers), total, err)
+	}
+
+	// The rule counters only look inside the window
+	if n, err := store.Transfers().CountSentSince(ctx, a.ID, now.Add(-time.Hour)); err != nil || n != 1 {
+		t.Errorf("CountSentSince returned %d, %v, want 1", n, err)
+	}
+	if n, err := store.Transfers().CountCompletedBetween(ctx, a.ID, b.ID); err != nil || n != 2 {
+		t.Errorf("CountCompletedBetween returned %d, %v, want 2", n, err)
+	}
+	if n, err := store.Transfers().CountSendersSince(ctx, b.ID, c.ID, now.Add(-3*time.Hour)); err != nil || n != 1 {
+		t.Errorf("CountSendersSince returned %d, %v, want 1", n, err)
+	}
+
+	// Failed transfers are not counted
+	reason := "denied"
+	if err = store.Transfers().UpdateStatus(ctx, *first.TransferID, models.StatusFailed, now, &reason); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = store.Transfers().GetByIdemKey(ctx, first.IdemKey)
+	if got.Status != models.StatusFailed || got.FailReason == nil || *got.FailReason != reason || !got.UpdatedAt.Equal(now) {
+		t.Errorf("updated transfer is %+v", got)
+	}
+	if n, _ := store.Transfers().CountCompletedBetween(ctx, a.ID, b.ID); n != 1 {
+		t.Errorf("CountCompletedBetween counted %d after a failure, want 1", n)
+	}
+	if err = store.Transfers().UpdateStatus(ctx, 9999, models.StatusFailed, now, nil); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateStatus of a missing transfer returned %v, want ErrNotFound", err)
+	}
+}
+
+func testReviews(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	a, b := createUser(t, store, 1, 0), createUser(t, store, 2, 0)
+	transfer := createTransfer(t, store, a.ID, b.ID, 10, now)
+
+	review := models.TransferReview{TransferID: *transfer.TransferID, Rule: "new_recipient", Reason: "first transfer", Status: models.ReviewOpen, CreatedAt: now}
+	if err := store.Transfers().CreateReview(ctx, &review); err != nil || review.ID == 0 {
+		t.Fatalf("CreateReview returned id %d, %v", review.ID, err)
+	}
+
+	got, err := store.Transfers().GetReview(ctx, review.ID)
+	if err != nil || got.Rule != "new_recipient" || got.Transfer == nil || got.Transfer.IdemKey != transfer.IdemKey {
+		t.Errorf("GetReview returned %+v, %v", got, err)
+	}
+	if _, err = store.Transfers().GetReview(ctx, 9999); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetReview of a missing review returned %v, want ErrNotFound", err)
+	}
+
+	note := "checked"
+	if err = store.Transfers().ResolveReview(ctx, review.ID, models.ReviewApproved, &note, now); err != nil {
+		t.Fatal(err)
+	}
+	if open, _ := store.Transfers().ListReviews(ctx, models.ReviewOpen); len(open) != 0 {
+		t.Errorf("%d reviews still open", len(open))
+	}
+	approved, err := store.Transfers().ListReviews(ctx, models.ReviewApproved)
+	if err != nil || len(approved) != 1 || approved[0].ResolutionNote == nil || approved[0].ResolvedAt == nil || approved[0].Transfer == nil {
+		t.Errorf("ListReviews returned %+v, %v", approved, err)
+	}
+}
+
+func testLedger(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	user := createUser(t, store, 1, 0)
+	reference := "partner:pk_1:order-1"
+	metadata := `{"partner":"Cafe"}`
+
+	err := store.Ledger().Append(ctx,
+		models.PointLedger{UserID: user.ID, Change: 100, BalanceAfter: 100, EventType: models.EventAdjust, CreatedAt: now},
+		models.PointLedger{UserID: user.ID, Change: 50, BalanceAfter: 150, EventType: models.EventEarn, Reference: &reference, Metadata: &metadata, CreatedAt: now},
+	)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// A partner reference can be used once
+	err = store.Ledger().Append(ctx, models.PointLedger{UserID: user.ID, Change: 50, BalanceAfter: 200, EventType: models.EventEarn, Reference: &reference, CreatedAt: now})
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("duplicate reference returned %v, want ErrConflict", err)
+	}
+
+	entries, err := store.Ledger().ListByUser(ctx, user.ID)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ListByUser returned %d entries, %v, want 2", len(entries), err)
+	}
+	if entries[0].Change != 100 || entries[1].Reference == nil || *entries[1].Reference != reference || entries[1].Metadata == nil || !entries[1].CreatedAt.Equal(now) {
+		t.Errorf("ledger entries are %+v", entries)
+	}
+}
+
+func testAPIKeys(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	key := models.APIKey{KeyID: "pk_test", Name: "Cafe", SecretSalt: "salt-1", Scopes: []models.APIKeyScope{models.ScopeEarn, models.ScopeReadBalance}, CreatedAt: now}
+	if err := store.APIKeys().Create(ctx, &key); err != nil || key.ID == 0 {
+		t.Fatalf("Create returned id %d, %v", key.ID, err)
+	}
+	duplicate := key
+	if err := store.APIKeys().Create(ctx, &duplicate); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("duplicate key ID returned %v, want ErrConflict", err)
+	}
+
+	got, err := store.APIKeys().GetByKeyID(ctx, "pk_test")
+	if err != nil || got.ID != key.ID || got.SecretSalt != "salt-1" || len(got.Scopes) != 2 || !got.HasScope(models.ScopeReadBalance) {
+		t.Errorf("GetByKeyID returned %+v, %v", got, err)
+	}
+	if _, err = store.APIKeys().GetByKeyID(ctx, "pk_missing"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByKeyID of a missing key returned %v, want ErrNotFound", err)
+	}
+
+	rotatedAt := now.Add(time.Hour)
+	if err = store.APIKeys().Rotate(ctx, key.ID, "salt-2", rotatedAt); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = store.APIKeys().GetByID(ctx, key.ID)
+	if got.SecretSalt != "salt-2" || got.RotatedAt == nil || !got.RotatedAt.Equal(rotatedAt) {
+		t.Errorf("rotated key is %+v", got)
+	}
+
+	if err = store.APIKeys().Revoke(ctx, key.ID, rotatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.APIKeys().Revoke(ctx, key.ID, rotatedAt); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("revoking twice returned %v, want ErrNotFound", err)
+	}
+	if err = store.APIKeys().Rotate(ctx, key.ID, "salt-3", rotatedAt); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("rotating a revoked key returned %v, want ErrNotFound", err)
+	}
+
+	keys, err := store.APIKeys().List(ctx)
+	if err != nil || len(keys) != 1 || keys[0].RevokedAt == nil {
+		t.Errorf("List returned %+v, %v", keys, err)
+	}
+}
+
+func testNonces(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	key := models.APIKey{KeyID: "pk_test", Name: "Cafe", SecretSalt: "salt", Scopes: []models.APIKeyScope{models.ScopeEarn}, CreatedAt: now}
+	if err := store.APIKeys().Create(ctx, &key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.APIKeys().UseNonce(ctx, key.ID, "old", now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.APIKeys().UseNonce(ctx, key.ID, "new", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.APIKeys().UseNonce(ctx, key.ID, "new", now); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("reused nonce returned %v, want ErrConflict", err)
+	}
+
+	purged, err := store.APIKeys().PurgeNonces(ctx, now.Add(-time.Minute))
+	if err != nil || purged != 1 {
+		t.Errorf("PurgeNonces removed %d, %v, want 1", purged, err)
+	}
+	if err = store.APIKeys().UseNonce(ctx, key.ID, "old", now); err != nil {
+		t.Errorf("purged nonce could not be used again: %v", err)
+	}
+}
+
+func testAudit(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	entries := []models.AuditEntry{
+		{Actor: "alice", ActorRole: "admin", Action: models.AuditUserUpdate, EntityType: models.EntityUser, EntityID: 1, RequestID: "req-1", CreatedAt: now.Add(-time.Hour),
+			Changes: map[string]models.FieldChange{"first_name": {Before: "A", After: "B"}}},
+		{Actor: "bob", ActorRole: "support", Action: models.AuditUserStatusChange, EntityType: models.EntityUser, EntityID: 1, CreatedAt: now},
+		{Actor: "alice", ActorRole: "admin", Action: models.AuditAPIKeyCreate, EntityType: models.EntityAPIKey, EntityID: 7, CreatedAt: now},
+	}
+	for i := range entries {
+		if err := store.Audit().Append(ctx, &entries[i]); err != nil || entries[i].ID == 0 {
+			t.Fatalf("Append returned id %d, %v", entries[i].ID, err)
+		}
+	}
+
+	all, total, err := store.Audit().List(ctx, repository.AuditFilter{}, 10, 0)
+	if err != nil || total != 3 || all[0].ID != entries[2].ID || all[2].ID != entries[0].ID {
+		t.Fatalf("List returned %d entries, %v, want newest first", total, err)
+	}
+	if change := all[2].Changes["first_name"]; change.Before != "A" || change.After != "B" || all[2].RequestID != "req-1" {
+		t.Errorf("oldest entry is %+v", all[2])
+	}
+
+	filters := []struct {
+		filter repository.AuditFilter
+		want   int
+	}{
+		{repository.AuditFilter{Actor: "alice"}, 2},
+		{repository.AuditFilter{Action: models.AuditUserStatusChange}, 1},
+		{repository.AuditFilter{EntityType: models.EntityUser, EntityID: 1}, 2},
+		{repository.AuditFilter{RequestID: "req-1"}, 1},
+		{repository.AuditFilter{From: now.Add(-time.Minute)}, 2},
+		{repository.AuditFilter{To: now.Add(-time.Minute)}, 1},
+	}
+	for _, f := range filters {
+		if _, total, err := store.Audit().List(ctx, f.filter, 10, 0); err != nil || total != f.want {
+			t.Errorf("filter %+v matched %d, %v, want %d", f.filter, total, err, f.want)
+		}
+	}
+	if page, total, _ := store.Audit().List(ctx, repository.AuditFilter{}, 1, 1); total != 3 || len(page) != 1 || page[0].ID != entries[1].ID {
+		t.Errorf("second page is %+v", page)
+	}
+}
+
+func testWithinTx(t *testing.T, store repository.Store) {
+	ctx := context.Background()
+	user := createUser(t, store, 1, 100)
+	failure := errors.New("abort")
+
+	// Every write of a failed transaction is rolled back
+	err := store.WithinTx(ctx, func(tx repository.Store) error {
+		if _, err := tx.Users().AdjustPoints(ctx, user.ID, -40); err != nil {
+			return err
+		}
+		if err := tx.Ledger().Append(ctx, models.PointLedger{UserID: user.ID, Change: -40, BalanceAfter: 60, EventType: models.EventAdjust, CreatedAt: now}); err != nil {
+			return err
+		}
+		// Nested calls join the outer transaction
+		return tx.WithinTx(ctx, func(tx repository.Store) error {
+			if _, err := tx.Users().AdjustPoints(ctx, user.ID, -10); err != nil {
+				return err
+			}
+			return failure
+		})
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithinTx returned %v, want the error of fn", err)
+	}
+	got, _ := store.Users().GetByID(ctx, user.ID)
+	entries, _ := store.Ledger().ListByUser(ctx, user.ID)
+	if got.Points != 100 || len(entries) != 0 {
+		t.Errorf("rolled back transaction left balance %d and %d ledger entries", got.Points, len(entries))
+	}
+
+	err = store.WithinTx(ctx, func(tx repository.Store) error {
+		_, err := tx.Users().AdjustPoints(ctx, user.ID, -40)
+		return err
+	})
+	if got, _ = store.Users().GetByID(ctx, user.ID); err != nil || got.Points != 60 {
+		t.Errorf("committed transaction left balance %d, %v, want 60", got.Points, err)
+	}
+}
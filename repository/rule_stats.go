@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"temp-kbtg-backend/rules"
+	"time"
+)
+
+// ruleStats answers the questions asked by the fraud rules from a Store
+type ruleStats struct {
+	store Store
+}
+
+// RuleStats adapts a Store to the statistics needed by the rules engine
+func RuleStats(store Store) rules.Stats {
+	return ruleStats{store: store}
+}
+
+func (s ruleStats) TransfersSentSince(ctx context.Context, fromUserID int, since time.Time) (int, error) {
+	return s.store.Transfers().CountSentSince(ctx, fromUserID, since)
+}
+
+func (s ruleStats) CompletedTransfers(ctx context.Context, fromUserID, toUserID int) (int, error) {
+	return s.store.Transfers().CountCompletedBetween(ctx, fromUserID, toUserID)
+}
+
+func (s ruleStats) SendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (int, error) {
+	return s.store.Transfers().CountSendersSince(ctx, toUserID, excludeUserID, since)
+}
+
+func (s ruleStats) JoinedDate(ctx context.Context, userID int) (time.Time, error) {
+	user, err := s.store.Users().GetByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return user.JoinedDate, nil
+}
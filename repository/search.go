@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"temp-kbtg-backend/models"
+)
+
+// SearchUsers implements UserRepository.Search for stores without a
+// full-text index: the same matching as UserFilter.Search, newest first,
+// with a zero score
+func SearchUsers(ctx context.Context, users UserRepository, terms []string, limit, offset int) ([]models.UserSearchHit, int, error) {
+	page := UserPage{Sort: UserSortID, Desc: true, Limit: limit, Offset: offset}
+	matched, total, err := users.List(ctx, UserFilter{Search: terms}, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]models.UserSearchHit, len(matched))
+	for i, user := range matched {
+		hits[i] = models.UserSearchHit{User: user}
+	}
+	return hits, total, nil
+}
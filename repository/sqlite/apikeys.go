@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// apiKeyColumns lists the api_keys columns read by scanAPIKey
+const apiKeyColumns = `id, key_id, name, secret_salt, scopes, created_at, rotated_at, revoked_at`
+
+type apiKeyRepository struct {
+	q querier
+}
+
+// Helper function to scan a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes, createdAt string
+	var rotatedAt, revokedAt sql.NullString
+	err := row.Scan(&key.ID, &key.KeyID, &key.Name, &key.SecretSalt, &scopes, &createdAt, &rotatedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return models.APIKey{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	key.Scopes = models.SplitScopes(scopes)
+	key.CreatedAt = parseTime(createdAt)
+	key.RotatedAt = parseNullTime(rotatedAt)
+	key.RevokedAt = parseNullTime(revokedAt)
+	return key, nil
+}
+
+func (r apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	result, err := r.q.ExecContext(ctx, `
+		INSERT INTO api_keys (key_id, name, secret_salt, scopes, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, key.KeyID, key.Name, key.SecretSalt, models.JoinScopes(key.Scopes), key.CreatedAt.UTC().Format(time.RFC3339))
+	if database.IsUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	key.ID = int(id)
+	return nil
+}
+
+func (r apiKeyRepository) GetByID(ctx context.Context, id int) (models.APIKey, error) {
+	return scanAPIKey(r.q.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id))
+}
+
+func (r apiKeyRepository) GetByKeyID(ctx context.Context, keyID string) (models.APIKey, error) {
+	return scanAPIKey(r.q.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_id = ?", keyID))
+}
+
+func (r apiKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.q.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r apiKeyRepository) Rotate(ctx context.Context, id int, secretSalt string, at time.Time) error {
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE api_keys SET secret_salt = ?, rotated_at = ? WHERE id = ? AND revoked_at IS NULL
+	`, secretSalt, at.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL
+	`, at.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r apiKeyRepository) UseNonce(ctx context.Context, id int, nonce string, at time.Time) error {
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO api_key_nonces (api_key_id, nonce, created_at) VALUES (?, ?, ?)
+	`, id, nonce, at.UTC().Format(time.RFC3339))
+	if database.IsUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	return err
+}
+
+func (r apiKeyRepository) PurgeNonces(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.q.ExecContext(ctx, "DELETE FROM api_key_nonces WHERE created_at < ?", before.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
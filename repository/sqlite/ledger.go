@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"context"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+type ledgerRepository struct {
+	q querier
+}
+
+func (r ledgerRepository) Append(ctx context.Context, entries ...models.PointLedger) error {
+	for _, e := range entries {
+		_, err := r.q.ExecContext(ctx, `
+			INSERT INTO point_ledger (user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, e.UserID, e.Change, e.BalanceAfter, e.EventType, e.TransferID, e.Reference, e.Metadata, e.CreatedAt.UTC().Format(time.RFC3339))
+		if database.IsUniqueViolation(err) {
+			return repository.ErrConflict
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r ledgerRepository) ListByUser(ctx context.Context, userID int) ([]models.PointLedger, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, user_id, change, balance_after, event_type, transfer_id, reference, metadata, created_at
+		FROM point_ledger
+		WHERE user_id = ?
+		ORDER BY id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.PointLedger{}
+	for rows.Next() {
+		var e models.PointLedger
+		var createdAt string
+		err := rows.Scan(&e.ID, &e.UserID, &e.Change, &e.BalanceAfter, &e.EventType, &e.TransferID, &e.Reference, &e.Metadata, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		e.CreatedAt = parseTime(createdAt)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,16 @@
+//go:build !sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+)
+
+// Search matches with LIKE when the binary is built without FTS5, newest
+// first and unranked. Migration 0006 is not embedded then, so users_fts does
+// not exist.
+func (r userRepository) Search(ctx context.Context, terms []string, limit, offset int) ([]models.UserSearchHit, int, error) {
+	return repository.SearchUsers(ctx, r, terms, limit, offset)
+}
@@ -0,0 +1,74 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"unicode/utf8"
+)
+
+// trigramLength is the shortest term the trigram tokenizer can match
+const trigramLength = 3
+
+// Search ranks users with the users_fts index of migration 0006.
+// Terms shorter than a trigram cannot use the index and are matched with
+// LIKE instead; when no term is long enough the results are not ranked.
+func (r userRepository) Search(ctx context.Context, terms []string, limit, offset int) ([]models.UserSearchHit, int, error) {
+	var phrases, short []string
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) >= trigramLength {
+			phrases = append(phrases, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+		} else {
+			short = append(short, term)
+		}
+	}
+	if len(phrases) == 0 {
+		return repository.SearchUsers(ctx, r, terms, limit, offset)
+	}
+
+	// Every term must match, long ones through the index and short ones with LIKE
+	where, args := userWhere(repository.UserFilter{Search: short})
+	from := " FROM (SELECT " + userColumns + " FROM users" + where + ") AS u" +
+		" JOIN users_fts ON users_fts.rowid = u.id WHERE users_fts MATCH ?"
+	args = append(args, strings.Join(phrases, " AND "))
+
+	// Count total records
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.q.QueryContext(ctx, "SELECT "+qualify(userColumns)+", -bm25(users_fts)"+from+
+		" ORDER BY bm25(users_fts), u.id DESC LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []models.UserSearchHit{}
+	for rows.Next() {
+		var hit models.UserSearchHit
+		user := &hit.User
+		err := rows.Scan(&user.ID, &user.MembershipID, &user.FirstName, &user.LastName, &user.PhoneNumber, &user.Email,
+			&user.MembershipLevel, &user.Points, &user.Status, &user.ClosedAt, &user.JoinedDate, &user.CreatedAt, &user.UpdatedAt,
+			&user.Version, &hit.Score)
+		if err != nil {
+			return nil, 0, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, total, rows.Err()
+}
+
+// Helper function to prefix each column of a column list with the u alias,
+// since users_fts has columns of the same names
+func qualify(columns string) string {
+	fields := strings.Split(columns, ",")
+	for i, field := range fields {
+		fields[i] = "u." + strings.TrimSpace(field)
+	}
+	return strings.Join(fields, ", ")
+}
@@ -0,0 +1,154 @@
+//go:build sqlite_fts5
+
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/repository/sqlite"
+	"testing"
+	"time"
+)
+
+// Helper function to open a migrated store holding users with the given
+// first and last names, in order
+func searchStore(t *testing.T, names ...[2]string) (repository.Store, []models.User) {
+	t.Helper()
+	if err := database.InitDB(filepath.Join(t.TempDir(), "users.db"), false); err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+	t.Cleanup(database.CloseDB)
+	store := sqlite.New(database.DB)
+
+	var users []models.User
+	for i, name := range names {
+		user := models.User{
+			MembershipID:    fmt.Sprintf("LBK%06d", 500+i),
+			FirstName:       name[0],
+			LastName:        name[1],
+			PhoneNumber:     fmt.Sprintf("081-234-%04d", i),
+			Email:           fmt.Sprintf("user%d@example.com", i),
+			MembershipLevel: models.LevelBronze,
+		}
+		if err := store.Users().Create(context.Background(), &user); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		users = append(users, user)
+	}
+	return store, users
+}
+
+// Helper function to list the IDs of search hits
+func hitIDs(hits []models.UserSearchHit) []int {
+	ids := []int{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestSearchRanksThaiNames(t *testing.T) {
+	store, users := searchStore(t,
+		[2]string{"ใจดี", "ใจดี"},
+		[2]string{"สมชาย", "ใจดี"},
+		[2]string{"สมชาย", "ใจเย็น"},
+		[2]string{"สมหญิง", "รักดี"},
+	)
+	ctx := context.Background()
+
+	// The name matching twice ranks first although it is the oldest
+	hits, total, err := store.Users().Search(ctx, []string{"ใจดี"}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); total != 2 || len(got) != 2 || got[0] != users[0].ID || got[1] != users[1].ID {
+		t.Fatalf("hits are %v of %d, want [%d %d]", got, total, users[0].ID, users[1].ID)
+	}
+	if hits[0].Score <= hits[1].Score || hits[1].Score <= 0 {
+		t.Errorf("scores are %v and %v, want positive and descending", hits[0].Score, hits[1].Score)
+	}
+
+	tests := []struct {
+		name  string
+		terms []string
+		want  []int
+	}{
+		{"every term must match", []string{"สมชาย", "ใจดี"}, []int{users[1].ID}},
+		{"trigram inside a name", []string{"มชา"}, []int{users[1].ID, users[2].ID}},
+		{"phone number without dashes", []string{"0812340003"}, []int{users[3].ID}},
+		{"no match", []string{"ประเสริฐ"}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, total, err := store.Users().Search(ctx, tt.terms, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := hitIDs(hits)
+			sort.Ints(got)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) || total != len(tt.want) {
+				t.Errorf("hits are %v of %d, want %v", got, total, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatchesShortTermsWithLike(t *testing.T) {
+	store, users := searchStore(t,
+		[2]string{"สมชาย", "ใจดี"},
+		[2]string{"สมศรี", "ใจเย็น"},
+		[2]string{"สมหญิง", "รักดี"},
+	)
+	ctx := context.Background()
+
+	// Only terms shorter than a trigram: LIKE, newest first and unscored
+	hits, total, err := store.Users().Search(ctx, []string{"ใจ"}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); total != 2 || fmt.Sprint(got) != fmt.Sprint([]int{users[1].ID, users[0].ID}) {
+		t.Errorf("hits are %v of %d", got, total)
+	}
+	for _, hit := range hits {
+		if hit.Score != 0 {
+			t.Errorf("LIKE hit %d has score %v", hit.ID, hit.Score)
+		}
+	}
+
+	// A short term still filters the ranked matches of a long one
+	hits, total, err = store.Users().Search(ctx, []string{"สมศรี", "ดี"}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Errorf("hits are %v, want none", hitIDs(hits))
+	}
+	hits, total, err = store.Users().Search(ctx, []string{"สมหญิง", "ดี"}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); total != 1 || got[0] != users[2].ID || hits[0].Score <= 0 {
+		t.Errorf("hits are %+v of %d", hits, total)
+	}
+}
+
+func TestSearchSkipsClosedAccounts(t *testing.T) {
+	store, users := searchStore(t, [2]string{"สมชาย", "ใจดี"}, [2]string{"สมชาย", "ใจเย็น"})
+	ctx := context.Background()
+	if err := store.Users().SetStatus(ctx, users[0].ID, models.UserClosed, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, total, err := store.Users().Search(ctx, []string{"สมชาย"}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); total != 1 || got[0] != users[1].ID {
+		t.Errorf("hits are %v of %d, want only %d", got, total, users[1].ID)
+	}
+}
@@ -0,0 +1,75 @@
+// Package sqlite implements the repository interfaces on top of database/sql
+// and the SQLite schema created by database.InitDB.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/repository"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Store is the SQLite implementation of repository.Store
+type Store struct {
+	db *sql.DB // nil when the store is bound to a transaction
+	q  querier
+}
+
+// New creates a store backed by db
+func New(db *sql.DB) *Store {
+	return &Store{db: db, q: db}
+}
+
+func (s *Store) Users() repository.UserRepository {
+	return userRepository{q: s.q}
+}
+
+func (s *Store) Transfers() repository.TransferRepository {
+	return transferRepository{q: s.q}
+}
+
+func (s *Store) Ledger() repository.LedgerRepository {
+	return ledgerRepository{q: s.q}
+}
+
+func (s *Store) APIKeys() repository.APIKeyRepository {
+	return apiKeyRepository{q: s.q}
+}
+
+func (s *Store) Audit() repository.AuditRepository {
+	return auditRepository{q: s.q}
+}
+
+// WithinTx runs fn inside a write transaction. Calls made on a store that is
+// already bound to a transaction join that transaction.
+func (s *Store) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := database.Begin(ctx, s.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{q: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Store must satisfy repository.Store
+var _ repository.Store = (*Store)(nil)
@@ -0,0 +1,20 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/repository/repositorytest"
+	"temp-kbtg-backend/repository/sqlite"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		if err := database.InitDB(filepath.Join(t.TempDir(), "users.db"), false); err != nil {
+			t.Fatalf("init database: %v", err)
+		}
+		t.Cleanup(database.CloseDB)
+		return sqlite.New(database.DB)
+	})
+}
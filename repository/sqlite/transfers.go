@@ -0,0 +1,258 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// transferColumns lists the transfers columns read by scanTransfer
+const transferColumns = `t.id, t.from_user_id, t.to_user_id, t.amount, t.status, t.note, t.idempotency_key,
+		       t.created_at, t.updated_at, t.completed_at, t.fail_reason`
+
+// activeTransferStatuses are the statuses counted by the fraud rules
+const activeTransferStatuses = "('pending', 'processing', 'completed')"
+
+type transferRepository struct {
+	q querier
+}
+
+// Helper function to scan a row selected with transferColumns
+func scanTransfer(row rowScanner) (models.Transfer, error) {
+	var t models.Transfer
+	var id int
+	var note, completedAt, failReason sql.NullString
+	var createdAt, updatedAt string
+
+	err := row.Scan(&id, &t.FromUserID, &t.ToUserID, &t.Amount, &t.Status, &note, &t.IdemKey,
+		&createdAt, &updatedAt, &completedAt, &failReason)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Transfer{}, repository.ErrNotFound
+		}
+		return models.Transfer{}, err
+	}
+
+	t.TransferID = &id
+	if note.Valid {
+		t.Note = &note.String
+	}
+	if failReason.Valid {
+		t.FailReason = &failReason.String
+	}
+
+	// Parse timestamps
+	t.CreatedAt = parseTime(createdAt)
+	t.UpdatedAt = parseTime(updatedAt)
+	t.CompletedAt = parseNullTime(completedAt)
+
+	return t, nil
+}
+
+func (r transferRepository) Create(ctx context.Context, t *models.Transfer) error {
+	var completedAt *string
+	if t.CompletedAt != nil {
+		formatted := t.CompletedAt.UTC().Format(time.RFC3339)
+		completedAt = &formatted
+	}
+
+	result, err := r.q.ExecContext(ctx, `
+		INSERT INTO transfers (from_user_id, to_user_id, amount, status, note, idempotency_key, created_at, updated_at, completed_at, fail_reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, t.FromUserID, t.ToUserID, t.Amount, t.Status, t.Note, t.IdemKey,
+		t.CreatedAt.UTC().Format(time.RFC3339), t.UpdatedAt.UTC().Format(time.RFC3339), completedAt, t.FailReason)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	transferID := int(id)
+	t.TransferID = &transferID
+	return nil
+}
+
+func (r transferRepository) GetByIdemKey(ctx context.Context, idemKey string) (models.Transfer, error) {
+	return scanTransfer(r.q.QueryRowContext(ctx, "SELECT "+transferColumns+" FROM transfers t WHERE t.idempotency_key = ?", idemKey))
+}
+
+func (r transferRepository) ListByUser(ctx context.Context, userID, limit, offset int) ([]models.Transfer, int, error) {
+	// Count total records
+	var total int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transfers
+		WHERE from_user_id = ? OR to_user_id = ?
+	`, userID, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT `+transferColumns+`
+		FROM transfers t
+		WHERE t.from_user_id = ? OR t.to_user_id = ?
+		ORDER BY t.created_at DESC
+		LIMIT ? OFFSET ?
+	`, userID, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transfers := []models.Transfer{}
+	for rows.Next() {
+		t, err := scanTransfer(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, total, rows.Err()
+}
+
+func (r transferRepository) UpdateStatus(ctx context.Context, id int, status models.TransferStatus, at time.Time, failReason *string) error {
+	now := at.UTC().Format(time.RFC3339)
+	var completedAt *string
+	if status == models.StatusCompleted {
+		completedAt = &now
+	}
+
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE transfers SET status = ?, updated_at = ?, completed_at = COALESCE(?, completed_at), fail_reason = COALESCE(?, fail_reason)
+		WHERE id = ?
+	`, status, now, completedAt, failReason, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r transferRepository) CountSentSince(ctx context.Context, fromUserID int, since time.Time) (int, error) {
+	// timestamps in transfers are stored as RFC3339 text, so they compare lexically
+	var count int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transfers
+		WHERE from_user_id = ? AND created_at >= ? AND status IN `+activeTransferStatuses,
+		fromUserID, since.UTC().Format(time.RFC3339)).Scan(&count)
+	return count, err
+}
+
+func (r transferRepository) CountCompletedBetween(ctx context.Context, fromUserID, toUserID int) (int, error) {
+	var count int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transfers
+		WHERE from_user_id = ? AND to_user_id = ? AND status = ?
+	`, fromUserID, toUserID, models.StatusCompleted).Scan(&count)
+	return count, err
+}
+
+func (r transferRepository) CountSendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (int, error) {
+	var count int
+	err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT from_user_id) FROM transfers
+		WHERE to_user_id = ? AND from_user_id != ? AND created_at >= ? AND status IN `+activeTransferStatuses,
+		toUserID, excludeUserID, since.UTC().Format(time.RFC3339)).Scan(&count)
+	return count, err
+}
+
+// reviewColumns lists the transfer_reviews columns read by scanReview
+const reviewColumns = `r.id, r.transfer_id, r.rule, r.reason, r.status, r.resolution_note, r.created_at, r.resolved_at`
+
+// Helper function to scan a row selected with reviewColumns followed by transferColumns
+func scanReview(row rowScanner) (models.TransferReview, error) {
+	var r models.TransferReview
+	var note, resolvedAt sql.NullString
+	var createdAt string
+
+	transfer, err := scanTransferAfter(row, &r.ID, &r.TransferID, &r.Rule, &r.Reason, &r.Status, &note, &createdAt, &resolvedAt)
+	if err != nil {
+		return models.TransferReview{}, err
+	}
+
+	if note.Valid {
+		r.ResolutionNote = &note.String
+	}
+	r.CreatedAt = parseTime(createdAt)
+	r.ResolvedAt = parseNullTime(resolvedAt)
+	r.Transfer = &transfer
+	return r, nil
+}
+
+// Helper function to scan leading columns followed by transferColumns
+func scanTransferAfter(row rowScanner, leading ...interface{}) (models.Transfer, error) {
+	return scanTransfer(prefixScanner{row: row, leading: leading})
+}
+
+// prefixScanner scans extra leading columns before the destinations it is given
+type prefixScanner struct {
+	row     rowScanner
+	leading []interface{}
+}
+
+func (p prefixScanner) Scan(dest ...interface{}) error {
+	return p.row.Scan(append(append([]interface{}{}, p.leading...), dest...)...)
+}
+
+func (r transferRepository) CreateReview(ctx context.Context, review *models.TransferReview) error {
+	result, err := r.q.ExecContext(ctx, `
+		INSERT INTO transfer_reviews (transfer_id, rule, reason, status, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, review.TransferID, review.Rule, review.Reason, review.Status, review.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	review.ID = int(id)
+	return nil
+}
+
+func (r transferRepository) GetReview(ctx context.Context, id int) (models.TransferReview, error) {
+	return scanReview(r.q.QueryRowContext(ctx, `
+		SELECT `+reviewColumns+`, `+transferColumns+`
+		FROM transfer_reviews r
+		JOIN transfers t ON t.id = r.transfer_id
+		WHERE r.id = ?
+	`, id))
+}
+
+func (r transferRepository) ListReviews(ctx context.Context, status models.ReviewStatus) ([]models.TransferReview, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT `+reviewColumns+`, `+transferColumns+`
+		FROM transfer_reviews r
+		JOIN transfers t ON t.id = r.transfer_id
+		WHERE r.status = ?
+		ORDER BY r.created_at ASC
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.TransferReview{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r transferRepository) ResolveReview(ctx context.Context, id int, status models.ReviewStatus, note *string, at time.Time) error {
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE transfer_reviews SET status = ?, resolution_note = ?, resolved_at = ? WHERE id = ?
+	`, status, note, at.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
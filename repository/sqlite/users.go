@@ -0,0 +1,378 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// userColumns lists the users columns read by scanUser
+const userColumns = `id, membership_id, first_name, last_name, phone_number, email,
+		       membership_level, points, status, closed_at, joined_date, created_at, updated_at, version`
+
+// sqliteDateTime is the format written by CURRENT_TIMESTAMP into DATETIME columns
+const sqliteDateTime = "2006-01-02 15:04:05"
+
+type userRepository struct {
+	q querier
+}
+
+// Helper function to scan a row selected with userColumns
+func scanUser(row rowScanner) (models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.MembershipID,
+		&user.FirstName,
+		&user.LastName,
+		&user.PhoneNumber,
+		&user.Email,
+		&user.MembershipLevel,
+		&user.Points,
+		&user.Status,
+		&user.ClosedAt,
+		&user.JoinedDate,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
+	)
+	if err == sql.ErrNoRows {
+		return models.User{}, repository.ErrNotFound
+	}
+	return user, err
+}
+
+func (r userRepository) GetByID(ctx context.Context, id int) (models.User, error) {
+	return scanUser(r.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+// GetByIDForUpdate needs no row lock on SQLite, every transaction already
+// holds the database write lock from BEGIN IMMEDIATE
+func (r userRepository) GetByIDForUpdate(ctx context.Context, id int) (models.User, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r userRepository) GetByMembershipID(ctx context.Context, membershipID string) (models.User, error) {
+	return scanUser(r.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE membership_id = ?", membershipID))
+}
+
+func (r userRepository) List(ctx context.Context, filter repository.UserFilter, page repository.UserPage) ([]models.User, int, error) {
+	where, args := userWhere(filter)
+
+	// Count total records
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	column, direction, compare := userSortColumn(page.Sort), "ASC", ">"
+	if page.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	// Keyset pagination continues after the cursor instead of skipping rows
+	offset := page.Offset
+	if page.After != nil {
+		where += " AND (" + column + ", id) " + compare + " (?, ?)"
+		args = append(args, userSortArg(page.After.Value), page.After.ID)
+		offset = 0
+	}
+
+	rows, err := r.q.QueryContext(ctx, "SELECT "+userColumns+" FROM users"+where+
+		" ORDER BY "+column+" "+direction+", id "+direction+" LIMIT ? OFFSET ?", append(args, page.Limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+// Helper function to build the WHERE clause of a user listing
+func userWhere(filter repository.UserFilter) (string, []interface{}) {
+	conditions := []string{"membership_id != ?"}
+	args := []interface{}{models.SystemMembershipID}
+
+	// Closed accounts are hidden unless requested
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	} else {
+		conditions = append(conditions, "status != ?")
+		args = append(args, models.UserClosed)
+	}
+
+	for _, term := range filter.Search {
+		pattern := likePattern(term)
+		conditions = append(conditions, `(first_name LIKE ? ESCAPE '\' OR last_name LIKE ? ESCAPE '\'
+			OR email LIKE ? ESCAPE '\' OR phone_number LIKE ? ESCAPE '\' OR membership_id LIKE ? ESCAPE '\'
+			OR replace(replace(phone_number, '-', ''), ' ', '') LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern, pattern, pattern, pattern)
+	}
+	if filter.MembershipLevel != "" {
+		conditions = append(conditions, "membership_level = ?")
+		args = append(args, filter.MembershipLevel)
+	}
+	if filter.MinPoints != nil {
+		conditions = append(conditions, "points >= ?")
+		args = append(args, *filter.MinPoints)
+	}
+	if filter.MaxPoints != nil {
+		conditions = append(conditions, "points <= ?")
+		args = append(args, *filter.MaxPoints)
+	}
+
+	// joined_date holds both dates and CURRENT_TIMESTAMP values, datetime() normalizes them
+	if !filter.JoinedFrom.IsZero() {
+		conditions = append(conditions, "datetime(joined_date) >= ?")
+		args = append(args, filter.JoinedFrom.UTC().Format(sqliteDateTime))
+	}
+	if !filter.JoinedBefore.IsZero() {
+		conditions = append(conditions, "datetime(joined_date) < ?")
+		args = append(args, filter.JoinedBefore.UTC().Format(sqliteDateTime))
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Helper function to map a sort field to the expression to order by
+func userSortColumn(field repository.UserSortField) string {
+	if field == repository.UserSortJoinedDate {
+		return "datetime(joined_date)"
+	}
+	if !field.IsValid() {
+		return "id"
+	}
+	return string(field)
+}
+
+// Helper function to bind a cursor value the way userSortColumn compares it
+func userSortArg(value interface{}) interface{} {
+	if at, ok := value.(time.Time); ok {
+		return at.UTC().Format(sqliteDateTime)
+	}
+	return value
+}
+
+// Helper function to build a LIKE pattern matching term anywhere, with its
+// wildcards escaped
+func likePattern(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term) + "%"
+}
+
+func (r userRepository) Create(ctx context.Context, user *models.User) error {
+	if user.Status == "" {
+		user.Status = models.UserActive
+	}
+
+	result, err := r.q.ExecContext(ctx, `
+		INSERT INTO users (membership_id, first_name, last_name, phone_number, email, membership_level, points, status, joined_date)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, user.MembershipID, user.FirstName, user.LastName, user.PhoneNumber, user.Email, user.MembershipLevel, user.Points, user.Status)
+	if database.IsUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	created, err := r.GetByID(ctx, int(id))
+	if err != nil {
+		return err
+	}
+	*user = created
+	return nil
+}
+
+func (r userRepository) Update(ctx context.Context, id int, req models.UpdateUserRequest) error {
+	// Build update query dynamically
+	updates := []string{}
+	args := []interface{}{}
+
+	if req.FirstName != "" {
+		updates = append(updates, "first_name = ?")
+		args = append(args, req.FirstName)
+	}
+	if req.LastName != "" {
+		updates = append(updates, "last_name = ?")
+		args = append(args, req.LastName)
+	}
+	if req.PhoneNumber != "" {
+		updates = append(updates, "phone_number = ?")
+		args = append(args, req.PhoneNumber)
+	}
+	if req.Email != "" {
+		updates = append(updates, "email = ?")
+		args = append(args, req.Email)
+	}
+	if req.MembershipLevel != "" {
+		updates = append(updates, "membership_level = ?")
+		args = append(args, req.MembershipLevel)
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP, version = version + 1")
+	args = append(args, id)
+
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id = ?", strings.Join(updates, ", "))
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if database.IsUniqueViolation(err) {
+		return repository.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r userRepository) AdjustPoints(ctx context.Context, id int, delta int) (int, error) {
+	// The balance check and the write are a single statement, so concurrent
+	// debits can never take the balance below zero
+	var balance int
+	err := r.q.QueryRowContext(ctx, `
+		UPDATE users SET points = points + ?, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = ? AND points + ? >= 0
+		RETURNING points
+	`, delta, id, delta).Scan(&balance)
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return 0, err
+		}
+		return 0, repository.ErrInsufficientPoints
+	}
+	return balance, err
+}
+
+func (r userRepository) SetStatus(ctx context.Context, id int, status models.UserStatus, at time.Time) error {
+	var closedAt *string
+	if status == models.UserClosed {
+		formatted := at.UTC().Format(sqliteDateTime)
+		closedAt = &formatted
+	}
+
+	result, err := r.q.ExecContext(ctx, `
+		UPDATE users SET status = ?, closed_at = ?, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ?
+	`, status, closedAt, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (r userRepository) NextMembershipID(ctx context.Context) (string, error) {
+	var lastID int
+	err := r.q.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM users").Scan(&lastID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("LBK%06d", lastID+1), nil
+}
+
+func (r userRepository) AddStatusChange(ctx context.Context, change *models.UserStatusChange) error {
+	result, err := r.q.ExecContext(ctx, `
+		INSERT INTO user_status_history (user_id, from_status, to_status, reason, actor, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, change.UserID, change.FromStatus, change.ToStatus, change.Reason, change.Actor, change.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	change.ID = int(id)
+	change.CreatedAt = change.CreatedAt.UTC().Truncate(time.Second)
+	return nil
+}
+
+func (r userRepository) ListStatusChanges(ctx context.Context, userID int) ([]models.UserStatusChange, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, user_id, from_status, to_status, reason, actor, created_at
+		FROM user_status_history
+		WHERE user_id = ?
+		ORDER BY id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.UserStatusChange{}
+	for rows.Next() {
+		var change models.UserStatusChange
+		var createdAt string
+		err := rows.Scan(&change.ID, &change.UserID, &change.FromStatus, &change.ToStatus, &change.Reason, &change.Actor, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		change.CreatedAt = parseTime(createdAt)
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+// Helper function to turn an UPDATE that matched no rows into ErrNotFound
+func requireRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Helper function to parse an RFC3339 timestamp, returning the zero time on failure
+func parseTime(value string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339, value)
+	return parsed
+}
+
+// Helper function to parse a nullable RFC3339 timestamp
+func parseNullTime(value sql.NullString) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value.String)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+func (r userRepository) PointsByStatus(ctx context.Context) (map[models.UserStatus]int, error) {
+	rows, err := r.q.QueryContext(ctx, "SELECT status, COALESCE(SUM(points), 0) FROM users WHERE membership_id != ? GROUP BY status", models.SystemMembershipID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[models.UserStatus]int{}
+	for rows.Next() {
+		var status models.UserStatus
+		var points int
+		if err := rows.Scan(&status, &points); err != nil {
+			return nil, err
+		}
+		totals[status] = points
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func allow(name string) Result {
+	return Result{Rule: name, Decision: DecisionAllow}
+}
+
+// VelocityRule limits the number of transfers a sender can make within a window
+type VelocityRule struct {
+	MaxTransfers int
+	Window       time.Duration
+	Decision     Decision
+}
+
+func (r VelocityRule) Name() string { return "velocity" }
+
+func (r VelocityRule) Evaluate(ctx context.Context, s Stats, c Candidate) (Result, error) {
+	count, err := s.TransfersSentSince(ctx, c.FromUserID, c.Now.Add(-r.Window))
+	if err != nil {
+		return Result{}, err
+	}
+
+	if count >= r.MaxTransfers {
+		return Result{
+			Rule:     r.Name(),
+			Decision: r.Decision,
+			Reason:   fmt.Sprintf("Sender exceeded %d transfers in %s", r.MaxTransfers, r.Window),
+		}, nil
+	}
+	return allow(r.Name()), nil
+}
+
+// NewRecipientRule limits the amount of the first transfer to a recipient
+type NewRecipientRule struct {
+	MaxAmount int
+	Decision  Decision
+}
+
+func (r NewRecipientRule) Name() string { return "new_recipient" }
+
+func (r NewRecipientRule) Evaluate(ctx context.Context, s Stats, c Candidate) (Result, error) {
+	if c.Amount <= r.MaxAmount {
+		return allow(r.Name()), nil
+	}
+
+	previous, err := s.CompletedTransfers(ctx, c.FromUserID, c.ToUserID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if previous == 0 {
+		return Result{
+			Rule:     r.Name(),
+			Decision: r.Decision,
+			Reason:   fmt.Sprintf("First transfer to this recipient exceeds %d points", r.MaxAmount),
+		}, nil
+	}
+	return allow(r.Name()), nil
+}
+
+// FanInRule detects many different senders transferring to one receiver
+type FanInRule struct {
+	MaxSenders int
+	Window     time.Duration
+	Decision   Decision
+}
+
+func (r FanInRule) Name() string { return "fan_in" }
+
+func (r FanInRule) Evaluate(ctx context.Context, s Stats, c Candidate) (Result, error) {
+	senders, err := s.SendersSince(ctx, c.ToUserID, c.FromUserID, c.Now.Add(-r.Window))
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Count the current sender as well
+	if senders+1 > r.MaxSenders {
+		return Result{
+			Rule:     r.Name(),
+			Decision: r.Decision,
+			Reason:   fmt.Sprintf("Receiver got transfers from more than %d senders in %s", r.MaxSenders, r.Window),
+		}, nil
+	}
+	return allow(r.Name()), nil
+}
+
+// AccountAgeRule limits transfers sent from recently joined accounts
+type AccountAgeRule struct {
+	MinAge    time.Duration
+	MaxAmount int
+	Decision  Decision
+}
+
+func (r AccountAgeRule) Name() string { return "account_age" }
+
+func (r AccountAgeRule) Evaluate(ctx context.Context, s Stats, c Candidate) (Result, error) {
+	if c.Amount <= r.MaxAmount {
+		return allow(r.Name()), nil
+	}
+
+	joinedDate, err := s.JoinedDate(ctx, c.FromUserID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if c.Now.Sub(joinedDate) < r.MinAge {
+		return Result{
+			Rule:     r.Name(),
+			Decision: r.Decision,
+			Reason:   fmt.Sprintf("Accounts younger than %d days cannot send more than %d points", int(r.MinAge.Hours()/24), r.MaxAmount),
+		}, nil
+	}
+	return allow(r.Name()), nil
+}
@@ -0,0 +1,145 @@
+package rules_test
+
+import (
+	"context"
+	"errors"
+	"temp-kbtg-backend/rules"
+	"testing"
+	"time"
+)
+
+// now is the time of every candidate transfer
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// fakeStats answers every question of rules.Stats with a fixed value
+type fakeStats struct {
+	sent      int       // Transfers sent in the window
+	completed int       // Completed transfers to the recipient
+	senders   int       // Other senders to the receiver in the window
+	joined    time.Time // Sender's joined date
+	err       error
+
+	since time.Time // Start of the last window asked for
+}
+
+func (s *fakeStats) TransfersSentSince(ctx context.Context, fromUserID int, since time.Time) (int, error) {
+	s.since = since
+	return s.sent, s.err
+}
+
+func (s *fakeStats) CompletedTransfers(ctx context.Context, fromUserID, toUserID int) (int, error) {
+	return s.completed, s.err
+}
+
+func (s *fakeStats) SendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (int, error) {
+	s.since = since
+	return s.senders, s.err
+}
+
+func (s *fakeStats) JoinedDate(ctx context.Context, userID int) (time.Time, error) {
+	return s.joined, s.err
+}
+
+// Helper function to evaluate a rule against a transfer of amount at now
+func evaluate(t *testing.T, rule rules.Rule, stats *fakeStats, amount int) rules.Decision {
+	t.Helper()
+	result, err := rule.Evaluate(context.Background(), stats, rules.Candidate{FromUserID: 1, ToUserID: 2, Amount: amount, Now: now})
+	if err != nil {
+		t.Fatalf("%s: %v", rule.Name(), err)
+	}
+	if result.Rule != rule.Name() {
+		t.Errorf("result names rule %q, want %q", result.Rule, rule.Name())
+	}
+	if result.Decision != rules.DecisionAllow && result.Reason == "" {
+		t.Errorf("%s decided %s without a reason", rule.Name(), result.Decision)
+	}
+	return result.Decision
+}
+
+func TestVelocityRule(t *testing.T) {
+	rule := rules.VelocityRule{MaxTransfers: 10, Window: 10 * time.Minute, Decision: rules.DecisionDeny}
+	tests := []struct {
+		sent int
+		want rules.Decision
+	}{
+		{0, rules.DecisionAllow},
+		{9, rules.DecisionAllow},
+		{10, rules.DecisionDeny}, // The eleventh transfer in the window
+		{25, rules.DecisionDeny},
+	}
+	for _, tt := range tests {
+		stats := &fakeStats{sent: tt.sent}
+		if got := evaluate(t, rule, stats, 100); got != tt.want {
+			t.Errorf("%d sent: got %s, want %s", tt.sent, got, tt.want)
+		}
+		if !stats.since.Equal(now.Add(-10 * time.Minute)) {
+			t.Errorf("window starts at %s", stats.since)
+		}
+	}
+}
+
+func TestNewRecipientRule(t *testing.T) {
+	rule := rules.NewRecipientRule{MaxAmount: 5000, Decision: rules.DecisionReview}
+	tests := []struct {
+		name      string
+		amount    int
+		completed int
+		want      rules.Decision
+	}{
+		{"at the limit", 5000, 0, rules.DecisionAllow},
+		{"above the limit to a new recipient", 5001, 0, rules.DecisionReview},
+		{"above the limit to a known recipient", 5001, 1, rules.DecisionAllow},
+	}
+	for _, tt := range tests {
+		if got := evaluate(t, rule, &fakeStats{completed: tt.completed}, tt.amount); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// Small amounts do not need the history
+	if got := evaluate(t, rule, &fakeStats{err: errors.New("unavailable")}, 100); got != rules.DecisionAllow {
+		t.Errorf("small amount got %s", got)
+	}
+}
+
+func TestFanInRule(t *testing.T) {
+	rule := rules.FanInRule{MaxSenders: 20, Window: time.Hour, Decision: rules.DecisionReview}
+	tests := []struct {
+		senders int // Other senders, the candidate's sender is counted as well
+		want    rules.Decision
+	}{
+		{0, rules.DecisionAllow},
+		{19, rules.DecisionAllow},
+		{20, rules.DecisionReview},
+	}
+	for _, tt := range tests {
+		stats := &fakeStats{senders: tt.senders}
+		if got := evaluate(t, rule, stats, 100); got != tt.want {
+			t.Errorf("%d other senders: got %s, want %s", tt.senders, got, tt.want)
+		}
+		if !stats.since.Equal(now.Add(-time.Hour)) {
+			t.Errorf("window starts at %s", stats.since)
+		}
+	}
+}
+
+func TestAccountAgeRule(t *testing.T) {
+	rule := rules.AccountAgeRule{MinAge: 7 * 24 * time.Hour, MaxAmount: 1000, Decision: rules.DecisionReview}
+	tests := []struct {
+		name   string
+		age    time.Duration
+		amount int
+		want   rules.Decision
+	}{
+		{"young account at the limit", time.Hour, 1000, rules.DecisionAllow},
+		{"young account above the limit", time.Hour, 1001, rules.DecisionReview},
+		{"just under the minimum age", 7*24*time.Hour - time.Second, 1001, rules.DecisionReview},
+		{"exactly the minimum age", 7 * 24 * time.Hour, 1001, rules.DecisionAllow},
+		{"old account", 365 * 24 * time.Hour, 50000, rules.DecisionAllow},
+	}
+	for _, tt := range tests {
+		if got := evaluate(t, rule, &fakeStats{joined: now.Add(-tt.age)}, tt.amount); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
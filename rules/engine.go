@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"temp-kbtg-backend/config"
+	"time"
+)
+
+// Decision represents the outcome of evaluating a rule
+type Decision string
+
+const (
+	DecisionAllow  Decision = "allow"
+	DecisionReview Decision = "review"
+	DecisionDeny   Decision = "deny"
+)
+
+// severity orders decisions so that the strictest one wins
+func (d Decision) severity() int {
+	switch d {
+	case DecisionDeny:
+		return 2
+	case DecisionReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Candidate is a transfer that has not moved any points yet
+type Candidate struct {
+	FromUserID int
+	ToUserID   int
+	Amount     int
+	Now        time.Time
+}
+
+// Result is the verdict of a single rule (or of the whole engine)
+type Result struct {
+	Rule     string   `json:"rule,omitempty"`
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// Stats provides the transfer history the rules look at.
+// It is implemented on top of the repositories by repository.RuleStats.
+type Stats interface {
+	// TransfersSentSince counts pending, processing and completed transfers sent since the given time
+	TransfersSentSince(ctx context.Context, fromUserID int, since time.Time) (int, error)
+	// CompletedTransfers counts completed transfers from one user to another
+	CompletedTransfers(ctx context.Context, fromUserID, toUserID int) (int, error)
+	// SendersSince counts distinct senders, other than excludeUserID, to a user since the given time
+	SendersSince(ctx context.Context, toUserID, excludeUserID int, since time.Time) (int, error)
+	JoinedDate(ctx context.Context, userID int) (time.Time, error)
+}
+
+// Rule checks a candidate transfer and returns allow/review/deny with a reason
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, s Stats, c Candidate) (Result, error)
+}
+
+// Engine evaluates a set of rules against a transfer
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine creates an engine with the given rules
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// New returns the engine used by the transfer API with the thresholds of cfg
+func New(cfg config.Rules) *Engine {
+	return NewEngine(
+		VelocityRule{MaxTransfers: cfg.Velocity.MaxTransfers, Window: cfg.Velocity.Window, Decision: Decision(cfg.Velocity.Decision)},
+		NewRecipientRule{MaxAmount: cfg.NewRecipient.MaxAmount, Decision: Decision(cfg.NewRecipient.Decision)},
+		FanInRule{MaxSenders: cfg.FanIn.MaxSenders, Window: cfg.FanIn.Window, Decision: Decision(cfg.FanIn.Decision)},
+		AccountAgeRule{MinAge: cfg.AccountAge.MinAge, MaxAmount: cfg.AccountAge.MaxAmount, Decision: Decision(cfg.AccountAge.Decision)},
+	)
+}
+
+// Default returns the engine of New with the default thresholds
+func Default() *Engine {
+	return New(config.Default().Rules)
+}
+
+// Use appends rules to the engine
+func (e *Engine) Use(rules ...Rule) {
+	e.rules = append(e.rules, rules...)
+}
+
+// Evaluate runs every rule and returns the strictest result.
+// The first rule that reaches the strictest decision provides the reason.
+func (e *Engine) Evaluate(ctx context.Context, s Stats, c Candidate) (Result, error) {
+	if c.Now.IsZero() {
+		c.Now = time.Now().UTC()
+	}
+
+	verdict := Result{Decision: DecisionAllow}
+	for _, rule := range e.rules {
+		result, err := rule.Evaluate(ctx, s, c)
+		if err != nil {
+			return Result{}, fmt.Errorf("rule %s: %v", rule.Name(), err)
+		}
+		if result.Rule == "" {
+			result.Rule = rule.Name()
+		}
+		if result.Decision.severity() > verdict.Decision.severity() {
+			verdict = result
+		}
+		if verdict.Decision == DecisionDeny {
+			break
+		}
+	}
+
+	return verdict, nil
+}
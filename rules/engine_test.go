@@ -0,0 +1,101 @@
+package rules_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"temp-kbtg-backend/config"
+	"temp-kbtg-backend/rules"
+	"testing"
+	"time"
+)
+
+// fixedRule returns the same decision for every transfer and counts its calls
+type fixedRule struct {
+	name     string
+	decision rules.Decision
+	err      error
+	calls    *int
+}
+
+func (r fixedRule) Name() string { return r.name }
+
+func (r fixedRule) Evaluate(ctx context.Context, s rules.Stats, c rules.Candidate) (rules.Result, error) {
+	if r.calls != nil {
+		*r.calls++
+	}
+	if r.err != nil {
+		return rules.Result{}, r.err
+	}
+	return rules.Result{Decision: r.decision, Reason: r.name + " says " + string(r.decision)}, nil
+}
+
+func TestEngineReturnsStrictestDecision(t *testing.T) {
+	allow := fixedRule{name: "a", decision: rules.DecisionAllow}
+	review := fixedRule{name: "r1", decision: rules.DecisionReview}
+	review2 := fixedRule{name: "r2", decision: rules.DecisionReview}
+	deny := fixedRule{name: "d", decision: rules.DecisionDeny}
+
+	tests := []struct {
+		name     string
+		rules    []rules.Rule
+		decision rules.Decision
+		rule     string
+	}{
+		{"no rules", nil, rules.DecisionAllow, ""},
+		{"all allow", []rules.Rule{allow, allow}, rules.DecisionAllow, ""},
+		{"review wins over allow", []rules.Rule{allow, review}, rules.DecisionReview, "r1"},
+		{"first review gives the reason", []rules.Rule{review, review2}, rules.DecisionReview, "r1"},
+		{"deny wins over review", []rules.Rule{review, deny, allow}, rules.DecisionDeny, "d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rules.NewEngine(tt.rules...).Evaluate(context.Background(), &fakeStats{}, rules.Candidate{Now: now})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Decision != tt.decision || result.Rule != tt.rule {
+				t.Errorf("got %s by %q, want %s by %q", result.Decision, result.Rule, tt.decision, tt.rule)
+			}
+		})
+	}
+}
+
+func TestEngineStopsAtDeny(t *testing.T) {
+	var calls int
+	engine := rules.NewEngine(
+		fixedRule{name: "d", decision: rules.DecisionDeny},
+		fixedRule{name: "later", decision: rules.DecisionReview, calls: &calls},
+	)
+	if result, _ := engine.Evaluate(context.Background(), &fakeStats{}, rules.Candidate{Now: now}); result.Rule != "d" || calls != 0 {
+		t.Errorf("got %+v with %d later calls", result, calls)
+	}
+}
+
+func TestEngineReportsRuleErrors(t *testing.T) {
+	engine := rules.NewEngine(fixedRule{name: "broken", err: errors.New("stats unavailable")})
+	_, err := engine.Evaluate(context.Background(), &fakeStats{}, rules.Candidate{Now: now})
+	if err == nil || !strings.Contains(err.Error(), "rule broken: stats unavailable") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestNewUsesConfiguredThresholds(t *testing.T) {
+	cfg := config.Default().Rules
+	cfg.Velocity = config.VelocityRule{MaxTransfers: 2, Window: time.Minute, Decision: "review"}
+	engine := rules.New(cfg)
+
+	result, err := engine.Evaluate(context.Background(), &fakeStats{sent: 2, joined: now.AddDate(-1, 0, 0)}, rules.Candidate{FromUserID: 1, ToUserID: 2, Amount: 10, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Decision != rules.DecisionReview || result.Rule != "velocity" {
+		t.Errorf("got %+v, want a velocity review", result)
+	}
+
+	// The defaults deny the eleventh transfer in ten minutes
+	result, _ = rules.Default().Evaluate(context.Background(), &fakeStats{sent: 10, joined: now.AddDate(-1, 0, 0)}, rules.Candidate{FromUserID: 1, ToUserID: 2, Amount: 10, Now: now})
+	if result.Decision != rules.DecisionDeny || result.Rule != "velocity" {
+		t.Errorf("default engine got %+v", result)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Domain errors returned by the services. Use errors.Is to test for them,
+// the returned error carries a more specific message.
+var (
+	ErrInvalidTransfer    = errors.New("invalid transfer")
+	ErrSelfTransfer       = errors.New("cannot transfer to yourself")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInsufficientPoints = errors.New("insufficient points")
+	ErrAccountStatus      = errors.New("account status does not allow the transfer")
+	ErrTransferDenied     = errors.New("transfer denied by fraud rules")
+	ErrReviewNotFound     = errors.New("review not found")
+	ErrReviewResolved     = errors.New("review already resolved")
+	ErrDuplicateReference = errors.New("partner reference already used")
+)
+
+// Error is a domain error with a message meant for the caller
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Helper function to create an Error of the given kind
+func newError(kind error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// DeniedError is returned when a fraud rule denies a transfer.
+// The transfer is still recorded as failed and returned alongside the error.
+type DeniedError struct {
+	Rule   string
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	return e.Reason
+}
+
+func (e *DeniedError) Unwrap() error {
+	return ErrTransferDenied
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"temp-kbtg-backend/audit"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"time"
+)
+
+// PartnerService credits and debits member points on behalf of partner API keys
+type PartnerService struct {
+	store repository.Store
+	now   func() time.Time
+}
+
+// NewPartnerService creates a partner service on top of the store
+func NewPartnerService(store repository.Store) *PartnerService {
+	return &PartnerService{
+		store: store,
+		now: func() time.Time {
+			return time.Now().UTC().Truncate(time.Second)
+		},
+	}
+}
+
+// partnerMetadata is stored in point_ledger.metadata to attribute an entry to its API key
+type partnerMetadata struct {
+	APIKeyID  int    `json:"apiKeyId"`
+	KeyID     string `json:"keyId"`
+	Partner   string `json:"partner"`
+	Reference string `json:"reference"`
+}
+
+// Earn credits points to a member. A reference can only be used once per key.
+func (s *PartnerService) Earn(ctx context.Context, key models.APIKey, req models.PartnerPointsRequest) (models.PartnerPointsResponse, error) {
+	return s.apply(ctx, key, req, models.EventEarn, req.Amount)
+}
+
+// Redeem debits points from a member. A reference can only be used once per key.
+func (s *PartnerService) Redeem(ctx context.Context, key models.APIKey, req models.PartnerPointsRequest) (models.PartnerPointsResponse, error) {
+	return s.apply(ctx, key, req, models.EventRedeem, -req.Amount)
+}
+
+// Balance returns the points of a member
+func (s *PartnerService) Balance(ctx context.Context, userID int) (models.PartnerBalanceResponse, error) {
+	user, err := s.member(ctx, s.store, userID)
+	if err != nil {
+		return models.PartnerBalanceResponse{}, err
+	}
+	return models.PartnerBalanceResponse{UserID: user.ID, MembershipID: user.MembershipID, Points: user.Points}, nil
+}
+
+// Helper function to change the balance and record the attributed ledger entry
+func (s *PartnerService) apply(ctx context.Context, key models.APIKey, req models.PartnerPointsRequest, event models.EventType, change int) (models.PartnerPointsResponse, error) {
+	if req.UserID < 1 || req.Amount < 1 || req.Reference == "" {
+		return models.PartnerPointsResponse{}, newError(ErrInvalidTransfer, "userId, amount and reference are required")
+	}
+
+	metadata, err := json.Marshal(partnerMetadata{APIKeyID: key.ID, KeyID: key.KeyID, Partner: key.Name, Reference: req.Reference})
+	if err != nil {
+		return models.PartnerPointsResponse{}, err
+	}
+	// The stored reference is namespaced by key so partners cannot collide
+	reference := "partner:" + key.KeyID + ":" + req.Reference
+	meta := string(metadata)
+
+	now := s.now()
+	var balance int
+	err = s.store.WithinTx(ctx, func(tx repository.Store) error {
+		user, err := s.member(ctx, tx, req.UserID)
+		if err != nil {
+			return err
+		}
+
+		if event == models.EventEarn && !user.Status.CanReceive() {
+			return newError(ErrAccountStatus, "Account is %s and cannot earn points", user.Status)
+		}
+		if event == models.EventRedeem && !user.Status.CanSend() {
+			return newError(ErrAccountStatus, "Account is %s and cannot redeem points", user.Status)
+		}
+
+		balance, err = tx.Users().AdjustPoints(ctx, user.ID, change)
+		if err == repository.ErrInsufficientPoints {
+			return newError(ErrInsufficientPoints, "Insufficient points. Available: %d, Required: %d", user.Points, req.Amount)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Ledger().Append(ctx, models.PointLedger{
+			UserID:       user.ID,
+			Change:       change,
+			BalanceAfter: balance,
+			EventType:    event,
+			Reference:    &reference,
+			Metadata:     &meta,
+			CreatedAt:    now,
+		})
+		if err == repository.ErrConflict {
+			return newError(ErrDuplicateReference, "Reference %s has already been used", req.Reference)
+		}
+		if err != nil {
+			return err
+		}
+
+		action := models.AuditPartnerEarn
+		if event == models.EventRedeem {
+			action = models.AuditPartnerRedeem
+		}
+		return audit.Record(ctx, tx, action, models.EntityUser, user.ID, map[string]models.FieldChange{
+			"points": {Before: user.Points, After: balance},
+		})
+	})
+	if err != nil {
+		return models.PartnerPointsResponse{}, err
+	}
+
+	msg := "partner points earned"
+	if event == models.EventRedeem {
+		msg = "partner points redeemed"
+	}
+	slog.InfoContext(ctx, msg,
+		"key_id", key.KeyID,
+		"user_id", req.UserID,
+		"amount", req.Amount,
+		"reference", req.Reference,
+	)
+	return models.PartnerPointsResponse{
+		UserID:    req.UserID,
+		Change:    change,
+		Balance:   balance,
+		Reference: req.Reference,
+		CreatedAt: now,
+	}, nil
+}
+
+// Helper function to load a member, hiding the system account from partners
+func (s *PartnerService) member(ctx context.Context, store repository.Store, userID int) (models.User, error) {
+	user, err := store.Users().GetByIDForUpdate(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) || user.MembershipID == models.SystemMembershipID {
+		return models.User{}, newError(ErrUserNotFound, "User not found")
+	}
+	return user, err
+}
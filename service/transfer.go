@@ -0,0 +1,350 @@
+// Package service holds the business logic of the points system behind a
+// plain Go API, so the HTTP handlers, a CLI or batch jobs can share it.
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"temp-kbtg-backend/audit"
+	"temp-kbtg-backend/metrics"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/rules"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransferService moves points between users
+type TransferService struct {
+	store repository.Store
+	rules *rules.Engine
+	now   func() time.Time
+}
+
+// NewTransferService creates a transfer service that checks transfers against the given rules
+func NewTransferService(store repository.Store, engine *rules.Engine) *TransferService {
+	return &TransferService{
+		store: store,
+		rules: engine,
+		now: func() time.Time {
+			return time.Now().UTC().Truncate(time.Second)
+		},
+	}
+}
+
+// Transfer validates and records a transfer. Completed transfers move the
+// points at once, transfers flagged by a rule are held as pending for review.
+// A denied transfer is recorded as failed and returned with a *DeniedError.
+func (s *TransferService) Transfer(ctx context.Context, req models.TransferCreateRequest) (models.Transfer, error) {
+	// Validate required fields
+	if req.FromUserID < 1 || req.ToUserID < 1 || req.Amount < 1 {
+		return models.Transfer{}, newError(ErrInvalidTransfer, "fromUserId, toUserId, and amount must be greater than 0")
+	}
+
+	// Check if trying to transfer to self
+	if req.FromUserID == req.ToUserID {
+		return models.Transfer{}, newError(ErrSelfTransfer, "Cannot transfer to yourself")
+	}
+
+	var transfer models.Transfer
+	var verdict rules.Result
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		users, err := lockUsers(ctx, tx, req.FromUserID, req.ToUserID)
+		if err != nil {
+			return err
+		}
+
+		// Check if sender exists and has enough points
+		sender, ok := users[req.FromUserID]
+		if !ok {
+			return newError(ErrUserNotFound, "Sender user not found")
+		}
+
+		// Frozen, suspended and closed accounts cannot send points
+		if !sender.Status.CanSend() {
+			return newError(ErrAccountStatus, "Sender account is %s", sender.Status)
+		}
+
+		if sender.Points < req.Amount {
+			return newError(ErrInsufficientPoints, "Insufficient points. Available: %d, Required: %d", sender.Points, req.Amount)
+		}
+
+		// Check if receiver exists
+		receiver, ok := users[req.ToUserID]
+		if !ok {
+			return newError(ErrUserNotFound, "Receiver user not found")
+		}
+
+		// Frozen and closed accounts cannot receive points
+		if !receiver.Status.CanReceive() {
+			return newError(ErrAccountStatus, "Receiver account is %s", receiver.Status)
+		}
+
+		// Evaluate fraud and velocity rules before any points move
+		now := s.now()
+		verdict, err = s.rules.Evaluate(ctx, repository.RuleStats(tx), rules.Candidate{
+			FromUserID: req.FromUserID,
+			ToUserID:   req.ToUserID,
+			Amount:     req.Amount,
+			Now:        now,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Denied transfers are recorded as failed, reviewed ones wait as pending
+		transfer = models.Transfer{
+			IdemKey:    uuid.New().String(),
+			FromUserID: req.FromUserID,
+			ToUserID:   req.ToUserID,
+			Amount:     req.Amount,
+			Status:     models.StatusCompleted,
+			Note:       req.Note,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		switch verdict.Decision {
+		case rules.DecisionDeny:
+			transfer.Status = models.StatusFailed
+			transfer.FailReason = &verdict.Reason
+		case rules.DecisionReview:
+			transfer.Status = models.StatusPending
+		default:
+			transfer.CompletedAt = &now
+		}
+
+		// Create transfer record
+		if err = tx.Transfers().Create(ctx, &transfer); err != nil {
+			return err
+		}
+		changes, err := audit.Diff(nil, transfer)
+		if err != nil {
+			return err
+		}
+		if err = audit.Record(ctx, tx, models.AuditTransferCreate, models.EntityTransfer, *transfer.TransferID, changes); err != nil {
+			return err
+		}
+
+		switch transfer.Status {
+		case models.StatusCompleted:
+			return moveBalances(ctx, tx, transfer, now)
+		case models.StatusPending:
+			// Route the transfer to the review queue
+			return tx.Transfers().CreateReview(ctx, &models.TransferReview{
+				TransferID: *transfer.TransferID,
+				Rule:       verdict.Rule,
+				Reason:     verdict.Reason,
+				Status:     models.ReviewOpen,
+				CreatedAt:  now,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	if transfer.Status == models.StatusFailed {
+		metrics.ObserveTransfer(transfer.Status, verdict.Rule, transfer.Amount)
+		logTransfer(ctx, "transfer denied", transfer, slog.String("rule", verdict.Rule))
+		return transfer, &DeniedError{Rule: verdict.Rule, Reason: verdict.Reason}
+	}
+	metrics.ObserveTransfer(transfer.Status, metrics.ReasonNone, transfer.Amount)
+	if transfer.Status == models.StatusPending {
+		logTransfer(ctx, "transfer held for review", transfer, slog.String("rule", verdict.Rule))
+	} else {
+		logTransfer(ctx, "transfer completed", transfer)
+	}
+	return transfer, nil
+}
+
+// Get returns a transfer by its idempotency key
+func (s *TransferService) Get(ctx context.Context, idemKey string) (models.Transfer, error) {
+	return s.store.Transfers().GetByIdemKey(ctx, idemKey)
+}
+
+// History returns a page of transfers sent or received by a user and the total count
+func (s *TransferService) History(ctx context.Context, userID, page, pageSize int) ([]models.Transfer, int, error) {
+	return s.store.Transfers().ListByUser(ctx, userID, pageSize, (page-1)*pageSize)
+}
+
+// Reviews returns the transfers held for review with the given status
+func (s *TransferService) Reviews(ctx context.Context, status models.ReviewStatus) ([]models.TransferReview, error) {
+	return s.store.Transfers().ListReviews(ctx, status)
+}
+
+// ApproveReview completes a held transfer and moves the points
+func (s *TransferService) ApproveReview(ctx context.Context, reviewID int, note string) (models.Transfer, error) {
+	return s.resolveReview(ctx, reviewID, models.ReviewApproved, note)
+}
+
+// RejectReview marks a held transfer as failed
+func (s *TransferService) RejectReview(ctx context.Context, reviewID int, note string) (models.Transfer, error) {
+	return s.resolveReview(ctx, reviewID, models.ReviewRejected, note)
+}
+
+// Helper function to approve or reject an open review
+func (s *TransferService) resolveReview(ctx context.Context, reviewID int, resolution models.ReviewStatus, note string) (models.Transfer, error) {
+	var idemKey string
+	var amount int
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		// Load the review together with the held transfer
+		review, err := tx.Transfers().GetReview(ctx, reviewID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return newError(ErrReviewNotFound, "Review not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		if review.Status != models.ReviewOpen {
+			return newError(ErrReviewResolved, "Review has already been %s", review.Status)
+		}
+
+		transfer := *review.Transfer
+		idemKey, amount = transfer.IdemKey, transfer.Amount
+		now := s.now()
+		var resolutionNote *string
+		if note != "" {
+			resolutionNote = &note
+		}
+
+		if resolution == models.ReviewApproved {
+			// Move the points now that the transfer is approved
+			err = moveBalances(ctx, tx, transfer, now)
+			if errors.Is(err, ErrInsufficientPoints) {
+				return newError(ErrInsufficientPoints, "Sender no longer has enough points for this transfer")
+			}
+			if err != nil {
+				return err
+			}
+			err = tx.Transfers().UpdateStatus(ctx, *transfer.TransferID, models.StatusCompleted, now, nil)
+		} else {
+			failReason := "Rejected in review: " + review.Reason
+			if note != "" {
+				failReason += " (" + note + ")"
+			}
+			err = tx.Transfers().UpdateStatus(ctx, *transfer.TransferID, models.StatusFailed, now, &failReason)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = tx.Transfers().ResolveReview(ctx, reviewID, resolution, resolutionNote, now); err != nil {
+			return err
+		}
+
+		// Audit the review together with the transfer it releases or fails
+		resolved, err := tx.Transfers().GetReview(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+		changes, err := audit.Diff(review, resolved)
+		if err != nil {
+			return err
+		}
+		action := models.AuditReviewReject
+		if resolution == models.ReviewApproved {
+			action = models.AuditReviewApprove
+		}
+		return audit.Record(ctx, tx, action, models.EntityTransferReview, reviewID, changes)
+	})
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	if resolution == models.ReviewApproved {
+		metrics.ObserveTransfer(models.StatusCompleted, metrics.ReasonNone, amount)
+	} else {
+		metrics.ObserveTransfer(models.StatusFailed, metrics.ReasonReviewRejected, amount)
+	}
+
+	transfer, err := s.store.Transfers().GetByIdemKey(ctx, idemKey)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	msg := "transfer review rejected"
+	if resolution == models.ReviewApproved {
+		msg = "transfer review approved"
+	}
+	logTransfer(ctx, msg, transfer, slog.Int("review_id", reviewID))
+	return transfer, nil
+}
+
+// Helper function to log a transfer event with IDs and amount only
+func logTransfer(ctx context.Context, msg string, transfer models.Transfer, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{
+		slog.String("idem_key", transfer.IdemKey),
+		slog.Int("from_user_id", transfer.FromUserID),
+		slog.Int("to_user_id", transfer.ToUserID),
+		slog.Int("amount", transfer.Amount),
+		slog.String("status", string(transfer.Status)),
+	}, attrs...)
+	if transfer.TransferID != nil {
+		attrs = append(attrs, slog.Int("transfer_id", *transfer.TransferID))
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, msg, attrs...)
+}
+
+// Helper function to move points for a transfer and record both ledger entries.
+// Balances are changed with conditional updates so a balance can never go negative.
+func moveBalances(ctx context.Context, tx repository.Store, transfer models.Transfer, now time.Time) error {
+	// Check account status of both sides
+	users, err := lockUsers(ctx, tx, transfer.FromUserID, transfer.ToUserID)
+	if err != nil {
+		return err
+	}
+	sender, receiver := users[transfer.FromUserID], users[transfer.ToUserID]
+	if !sender.Status.CanSend() {
+		return newError(ErrAccountStatus, "Sender account is %s", sender.Status)
+	}
+	if !receiver.Status.CanReceive() {
+		return newError(ErrAccountStatus, "Receiver account is %s", receiver.Status)
+	}
+
+	// Deduct sender points only if the balance still covers the amount
+	senderBalance, err := tx.Users().AdjustPoints(ctx, transfer.FromUserID, -transfer.Amount)
+	if err == repository.ErrInsufficientPoints {
+		return newError(ErrInsufficientPoints, "Insufficient points. Required: %d", transfer.Amount)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Add receiver points
+	receiverBalance, err := tx.Users().AdjustPoints(ctx, transfer.ToUserID, transfer.Amount)
+	if err != nil {
+		return err
+	}
+
+	// Record in ledger - sender (debit) and receiver (credit)
+	return tx.Ledger().Append(ctx,
+		models.PointLedger{UserID: transfer.FromUserID, Change: -transfer.Amount, BalanceAfter: senderBalance, EventType: models.EventTransferOut, TransferID: transfer.TransferID, CreatedAt: now},
+		models.PointLedger{UserID: transfer.ToUserID, Change: transfer.Amount, BalanceAfter: receiverBalance, EventType: models.EventTransferIn, TransferID: transfer.TransferID, CreatedAt: now},
+	)
+}
+
+// Helper function to lock the balance rows of both users in id order, so two
+// transfers in opposite directions cannot deadlock. Missing users are left out
+// of the returned map.
+func lockUsers(ctx context.Context, tx repository.Store, fromUserID, toUserID int) (map[int]models.User, error) {
+	ids := []int{fromUserID, toUserID}
+	if fromUserID > toUserID {
+		ids = []int{toUserID, fromUserID}
+	}
+
+	users := map[int]models.User{}
+	for _, id := range ids {
+		user, err := tx.Users().GetByIDForUpdate(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		users[id] = user
+	}
+	return users, nil
+}
@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"temp-kbtg-backend/database"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/repository/sqlite"
+	"temp-kbtg-backend/rules"
+	"temp-kbtg-backend/service"
+	"testing"
+	"time"
+)
+
+// Helper function to open a fresh SQLite database in a temp file, with the
+// same WAL, busy_timeout and _txlock=immediate settings as the server
+func newSQLiteStore(t *testing.T) repository.Store {
+	t.Helper()
+	if err := database.InitDB(filepath.Join(t.TempDir(), "users.db"), false); err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+	t.Cleanup(database.CloseDB)
+	return sqlite.New(database.DB)
+}
+
+// Helper function to create members holding the given points. Each balance
+// is credited with an adjust ledger entry so the ledger matches it.
+func createMembers(t *testing.T, store repository.Store, points ...int) []int {
+	t.Helper()
+	ctx := context.Background()
+	ids := make([]int, len(points))
+	for i, balance := range points {
+		err := store.WithinTx(ctx, func(tx repository.Store) error {
+			user := models.User{
+				MembershipID:    fmt.Sprintf("TST%06d", i+1),
+				FirstName:       "Member",
+				LastName:        fmt.Sprint(i + 1),
+				PhoneNumber:     "081-234-5678",
+				Email:           fmt.Sprintf("member%d@example.com", i+1),
+				MembershipLevel: models.LevelBronze,
+				Points:          balance,
+			}
+			if err := tx.Users().Create(ctx, &user); err != nil {
+				return err
+			}
+			ids[i] = user.ID
+			return tx.Ledger().Append(ctx, models.PointLedger{
+				UserID:       user.ID,
+				Change:       balance,
+				BalanceAfter: balance,
+				EventType:    models.EventAdjust,
+				CreatedAt:    time.Now().UTC(),
+			})
+		})
+		if err != nil {
+			t.Fatalf("create member %d: %v", i+1, err)
+		}
+	}
+	return ids
+}
+
+// Helper function to check that no balance is negative and that every
+// balance equals the sum of the user's ledger entries. Returns the total.
+func checkBalances(t *testing.T, store repository.Store, ids []int) int {
+	t.Helper()
+	ctx := context.Background()
+	total := 0
+	for _, id := range ids {
+		user, err := store.Users().GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get user %d: %v", id, err)
+		}
+		if user.Points < 0 {
+			t.Errorf("user %d has a negative balance: %d", id, user.Points)
+		}
+
+		entries, err := store.Ledger().ListByUser(ctx, id)
+		if err != nil {
+			t.Fatalf("list ledger of user %d: %v", id, err)
+		}
+		sum := 0
+		for _, entry := range entries {
+			sum += entry.Change
+		}
+		if sum != user.Points {
+			t.Errorf("user %d has balance %d but ledger sum %d", id, user.Points, sum)
+		}
+		total += user.Points
+	}
+	return total
+}
+
+func TestConcurrentTransfers(t *testing.T) {
+	store := newSQLiteStore(t)
+
+	// Small balances so many transfers race for the same points and fail
+	balances := []int{500, 500, 500, 500, 500, 500, 500, 500, 500, 500}
+	ids := createMembers(t, store, balances...)
+	before := checkBalances(t, store, ids)
+
+	// No fraud rules, so every transfer either completes or lacks points
+	transfers := service.NewTransferService(store, rules.NewEngine())
+
+	const workers = 300
+	random := rand.New(rand.NewSource(1))
+	requests := make([]models.TransferCreateRequest, workers)
+	for i := range requests {
+		from := random.Intn(len(ids))
+		to := (from + 1 + random.Intn(len(ids)-1)) % len(ids)
+		requests[i] = models.TransferCreateRequest{FromUserID: ids[from], ToUserID: ids[to], Amount: 1 + random.Intn(200)}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	completed, insufficient := 0, 0
+	start := make(chan struct{})
+	for _, req := range requests {
+		wg.Add(1)
+		go func(req models.TransferCreateRequest) {
+			defer wg.Done()
+			<-start
+			_, err := transfers.Transfer(context.Background(), req)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				completed++
+			case errors.Is(err, service.ErrInsufficientPoints):
+				insufficient++
+			default:
+				t.Errorf("transfer %d -> %d of %d: %v", req.FromUserID, req.ToUserID, req.Amount, err)
+			}
+		}(req)
+	}
+	close(start)
+	wg.Wait()
+
+	if completed == 0 {
+		t.Fatal("no transfer completed")
+	}
+	t.Logf("%d transfers completed, %d rejected for insufficient points", completed, insufficient)
+
+	if after := checkBalances(t, store, ids); after != before {
+		t.Errorf("total points changed from %d to %d", before, after)
+	}
+}
+
+func TestConcurrentDebitsNeverOverdraw(t *testing.T) {
+	store := newSQLiteStore(t)
+	ids := createMembers(t, store, 1000)
+	ctx := context.Background()
+
+	// Twice as many debits as the balance covers, each in its own transaction.
+	// The conditional update in AdjustPoints has to reject exactly the excess.
+	const debits, amount = 200, 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < debits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := store.WithinTx(ctx, func(tx repository.Store) error {
+				after, err := tx.Users().AdjustPoints(ctx, ids[0], -amount)
+				if err != nil {
+					return err
+				}
+				return tx.Ledger().Append(ctx, models.PointLedger{
+					UserID: ids[0], Change: -amount, BalanceAfter: after, EventType: models.EventAdjust, CreatedAt: time.Now().UTC(),
+				})
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, repository.ErrInsufficientPoints):
+				t.Errorf("debit: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1000/amount {
+		t.Errorf("%d debits succeeded, want %d", succeeded, 1000/amount)
+	}
+	if total := checkBalances(t, store, ids); total != 0 {
+		t.Errorf("balance is %d, want 0", total)
+	}
+}
@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"temp-kbtg-backend/models"
+	"temp-kbtg-backend/repository"
+	"temp-kbtg-backend/repository/memory"
+	"temp-kbtg-backend/rules"
+	"temp-kbtg-backend/service"
+	"testing"
+	"time"
+)
+
+// Helper function to read a user's balance from the store
+func balance(t *testing.T, store repository.Store, id int) int {
+	t.Helper()
+	user, err := store.Users().GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get user %d: %v", id, err)
+	}
+	return user.Points
+}
+
+func TestTransferRejectsInvalidRequests(t *testing.T) {
+	store := memory.New()
+	ids := createMembers(t, store, 100, 100)
+	transfers := service.NewTransferService(store, rules.NewEngine())
+
+	tests := []struct {
+		name string
+		req  models.TransferCreateRequest
+		want error
+	}{
+		{"zero amount", models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[1]}, service.ErrInvalidTransfer},
+		{"self transfer", models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[0], Amount: 10}, service.ErrSelfTransfer},
+		{"unknown sender", models.TransferCreateRequest{FromUserID: 999, ToUserID: ids[1], Amount: 10}, service.ErrUserNotFound},
+		{"unknown receiver", models.TransferCreateRequest{FromUserID: ids[0], ToUserID: 999, Amount: 10}, service.ErrUserNotFound},
+		{"insufficient points", models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[1], Amount: 101}, service.ErrInsufficientPoints},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := transfers.Transfer(context.Background(), tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Failed transfers are rolled back without a trace
+	if checkBalances(t, store, ids) != 200 || balance(t, store, ids[0]) != 100 {
+		t.Error("rejected transfers changed the balances")
+	}
+	if _, total, _ := store.Transfers().ListByUser(context.Background(), ids[0], 10, 0); total != 0 {
+		t.Errorf("%d transfers recorded, want none", total)
+	}
+}
+
+func TestTransferMovesPoints(t *testing.T) {
+	store := memory.New()
+	ids := createMembers(t, store, 100, 50)
+	transfers := service.NewTransferService(store, rules.NewEngine())
+
+	transfer, err := transfers.Transfer(context.Background(), models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[1], Amount: 30})
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if transfer.Status != models.StatusCompleted || transfer.CompletedAt == nil {
+		t.Errorf("transfer is %s, want completed", transfer.Status)
+	}
+	if from, to := balance(t, store, ids[0]), balance(t, store, ids[1]); from != 70 || to != 80 {
+		t.Errorf("balances are %d and %d, want 70 and 80", from, to)
+	}
+	checkBalances(t, store, ids)
+
+	got, err := transfers.Get(context.Background(), transfer.IdemKey)
+	if err != nil || got.Amount != 30 {
+		t.Errorf("Get returned %+v, %v", got, err)
+	}
+}
+
+func TestTransferChecksAccountStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		sender   models.UserStatus
+		receiver models.UserStatus
+		wantErr  bool
+	}{
+		{"frozen sender", models.UserFrozen, models.UserActive, true},
+		{"suspended sender", models.UserSuspended, models.UserActive, true},
+		{"suspended receiver", models.UserActive, models.UserSuspended, false},
+		{"frozen receiver", models.UserActive, models.UserFrozen, true},
+		{"closed receiver", models.UserActive, models.UserClosed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.New()
+			ids := createMembers(t, store, 100, 100)
+			now := time.Now().UTC()
+			if err := store.Users().SetStatus(ctx, ids[0], tt.sender, now); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Users().SetStatus(ctx, ids[1], tt.receiver, now); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := service.NewTransferService(store, rules.NewEngine()).
+				Transfer(ctx, models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[1], Amount: 10})
+			if tt.wantErr && !errors.Is(err, service.ErrAccountStatus) {
+				t.Errorf("got %v, want %v", err, service.ErrAccountStatus)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("transfer: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransferDeniedByRule(t *testing.T) {
+	store := memory.New()
+	ids := createMembers(t, store, 100, 0)
+	engine := rules.NewEngine(rules.VelocityRule{MaxTransfers: 1, Window: time.Hour, Decision: rules.DecisionDeny})
+	transfers := service.NewTransferService(store, engine)
+	req := models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[1], Amount: 10}
+
+	if _, err := transfers.Transfer(context.Background(), req); err != nil {
+		t.Fatalf("first transfer: %v", err)
+	}
+
+	transfer, err := transfers.Transfer(context.Background(), req)
+	var denied *service.DeniedError
+	if !errors.As(err, &denied) || denied.Rule != "velocity" || !errors.Is(err, service.ErrTransferDenied) {
+		t.Fatalf("got %v, want a velocity denial", err)
+	}
+
+	// The denied transfer is kept as failed and moves no points
+	if transfer.Status != models.StatusFailed || transfer.FailReason == nil {
+		t.Errorf("denied transfer is %s, want failed with a reason", transfer.Status)
+	}
+	if got := balance(t, store, ids[0]); got != 90 {
+		t.Errorf("sender balance is %d, want 90", got)
+	}
+	checkBalances(t, store, ids)
+}
+
+func TestTransferReview(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	ids := createMembers(t, store, 1000, 0)
+	engine := rules.NewEngine(rules.NewRecipientRule{MaxAmount: 100, Decision: rules.DecisionReview})
+	transfers := service.NewTransferService(store, engine)
+
+	approved, err := transfers.Transfer(ctx, models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[1], Amount: 300})
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	rejected, err := transfers.Transfer(ctx, models.TransferCreateRequest{FromUserID: ids[0], ToUserID: ids[1], Amount: 200})
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if approved.Status != models.StatusPending || rejected.Status != models.StatusPending {
+		t.Fatalf("transfers are %s and %s, want pending", approved.Status, rejected.Status)
+	}
+	if got := balance(t, store, ids[0]); got != 1000 {
+		t.Errorf("held transfers moved points, sender balance is %d", got)
+	}
+
+	reviews, err := transfers.Reviews(ctx, models.ReviewOpen)
+	if err != nil || len(reviews) != 2 {
+		t.Fatalf("got %d open reviews (%v), want 2", len(reviews), err)
+	}
+
+	transfer, err := transfers.ApproveReview(ctx, reviews[0].ID, "checked with member")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if transfer.Status != models.StatusCompleted || transfer.Amount != 300 {
+		t.Errorf("approved transfer is %s of %d, want completed of 300", transfer.Status, transfer.Amount)
+	}
+
+	transfer, err = transfers.RejectReview(ctx, reviews[1].ID, "")
+	if err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if transfer.Status != models.StatusFailed {
+		t.Errorf("rejected transfer is %s, want failed", transfer.Status)
+	}
+
+	if _, err := transfers.ApproveReview(ctx, reviews[1].ID, ""); !errors.Is(err, service.ErrReviewResolved) {
+		t.Errorf("approving a resolved review returned %v, want %v", err, service.ErrReviewResolved)
+	}
+	if _, err := transfers.ApproveReview(ctx, 999, ""); !errors.Is(err, service.ErrReviewNotFound) {
+		t.Errorf("approving an unknown review returned %v, want %v", err, service.ErrReviewNotFound)
+	}
+
+	if from, to := balance(t, store, ids[0]), balance(t, store, ids[1]); from != 700 || to != 300 {
+		t.Errorf("balances are %d and %d, want 700 and 300", from, to)
+	}
+	checkBalances(t, store, ids)
+}
@@ -0,0 +1,85 @@
+// Package worker runs background jobs that must finish before the process exits.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Group tracks background workers and stops them together on shutdown
+type Group struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started atomic.Int32
+	running atomic.Int32
+}
+
+// NewGroup creates an empty worker group
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in the background. fn must return soon after ctx is cancelled.
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	g.started.Add(1)
+	g.running.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.running.Add(-1)
+		fn(g.ctx)
+		slog.Info("Worker stopped", "worker", name)
+	}()
+}
+
+// Check reports an error when the group is shutting down or a worker has exited early
+func (g *Group) Check() error {
+	if g.ctx.Err() != nil {
+		return errors.New("shutting down")
+	}
+	if started, running := g.started.Load(), g.running.Load(); running != started {
+		return fmt.Errorf("%d of %d workers stopped", started-running, started)
+	}
+	return nil
+}
+
+// Every runs fn on a fixed interval until the group is shut down
+func (g *Group) Every(name string, interval time.Duration, fn func(ctx context.Context)) {
+	g.Go(name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	})
+}
+
+// Shutdown cancels every worker and waits for them until ctx expires
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}